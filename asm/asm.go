@@ -0,0 +1,525 @@
+// Package asm assembles SM83 source into machine code. Instructions are
+// written with the mnemonics of the cpu opcode tables, immediates replacing
+// their n8/n16/a8/a16/e8 placeholders, plus labels and the org, db and dw
+// directives.
+package asm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mrratatosk/oort-framework/memory"
+	"github.com/mrratatosk/oort-gb/cpu"
+)
+
+// Section is a run of bytes assembled from Origin on, each org directive
+// starts a new one.
+type Section struct {
+	Origin uint16
+	Bytes  []uint8
+}
+
+type Error struct {
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Assemble translates src, resolving labels used before their definition.
+func Assemble(src string) ([]Section, error) {
+	a := assembler{labels: map[string]int{}}
+
+	for i, text := range strings.Split(src, "\n") {
+		if err := a.parse(text); err != nil {
+			return nil, &Error{i + 1, err.Error()}
+		}
+	}
+
+	sections := []Section{{}}
+	for _, s := range a.statements {
+		last := &sections[len(sections)-1]
+		if s.emit == nil {
+			if len(last.Bytes) != 0 {
+				sections = append(sections, Section{})
+				last = &sections[len(sections)-1]
+			}
+			last.Origin = s.pc
+			continue
+		}
+
+		bytes, err := s.emit(&a, s.pc)
+		if err != nil {
+			return nil, &Error{s.line, err.Error()}
+		}
+		last.Bytes = append(last.Bytes, bytes...)
+	}
+
+	if len(sections) > 1 && len(sections[len(sections)-1].Bytes) == 0 {
+		sections = sections[:len(sections)-1]
+	}
+
+	return sections, nil
+}
+
+// Load assembles src and writes the result to mem.
+func Load(mem *memory.Memory[uint16, uint8], src string) error {
+	sections, err := Assemble(src)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range sections {
+		mem.WriteRange(s.Origin, s.Bytes)
+	}
+
+	return nil
+}
+
+// statement is a line laid out by the first pass, emit produces its bytes once
+// every label is known. An org directive has no emit.
+type statement struct {
+	line int
+	pc   uint16
+	emit func(a *assembler, pc uint16) ([]uint8, error)
+}
+
+type assembler struct {
+	statements []statement
+	labels     map[string]int
+	pc         uint16
+	line       int
+}
+
+func (a *assembler) parse(text string) error {
+	a.line++
+
+	text = strings.TrimSpace(stripComment(text))
+	if i := strings.Index(text, ":"); i >= 0 && isIdentifier(text[:i]) {
+		label := text[:i]
+		if _, ok := a.labels[label]; ok {
+			return fmt.Errorf("label %s already defined", label)
+		}
+		if reserved[strings.ToUpper(label)] {
+			return fmt.Errorf("%s is not a valid label", label)
+		}
+
+		a.labels[label] = int(a.pc)
+		text = strings.TrimSpace(text[i+1:])
+	}
+
+	if text == "" {
+		return nil
+	}
+
+	mnemonic, rest, _ := strings.Cut(text, " ")
+	mnemonic = strings.ToUpper(mnemonic)
+	operands := splitOperands(rest)
+
+	switch mnemonic {
+	case "ORG":
+		if len(operands) != 1 {
+			return fmt.Errorf("org takes an address")
+		}
+
+		origin, err := a.eval(operands[0])
+		if err != nil {
+			return err
+		}
+
+		a.pc = uint16(origin)
+		a.statements = append(a.statements, statement{line: a.line, pc: a.pc})
+		return nil
+	case "DB":
+		return a.data(operands, 1)
+	case "DW":
+		return a.data(operands, 2)
+	}
+
+	return a.instruction(mnemonic, operands)
+}
+
+func (a *assembler) add(size int, emit func(a *assembler, pc uint16) ([]uint8, error)) {
+	a.statements = append(a.statements, statement{a.line, a.pc, emit})
+	a.pc += uint16(size)
+}
+
+func (a *assembler) data(operands []string, width int) error {
+	size := 0
+	for _, op := range operands {
+		if s, ok := unquote(op); ok && width == 1 {
+			size += len(s)
+		} else {
+			size += width
+		}
+	}
+
+	a.add(size, func(a *assembler, pc uint16) ([]uint8, error) {
+		var bytes []uint8
+		for _, op := range operands {
+			if s, ok := unquote(op); ok && width == 1 {
+				bytes = append(bytes, s...)
+				continue
+			}
+
+			kind := "n8"
+			if width == 2 {
+				kind = "n16"
+			}
+
+			b, err := a.encode(kind, op, pc)
+			if err != nil {
+				return nil, err
+			}
+			bytes = append(bytes, b...)
+		}
+
+		return bytes, nil
+	})
+
+	return nil
+}
+
+func (a *assembler) instruction(mnemonic string, operands []string) error {
+	mnemonic, operands = alias(mnemonic, operands)
+
+	for _, e := range encodings[mnemonic] {
+		imm, ok := e.match(operands)
+		if !ok {
+			continue
+		}
+
+		code, size := e.code, len(e.code)
+		for i := range imm {
+			if mnemonic == "JR" {
+				imm[i].kind = "rel8"
+			}
+			size += immediates[imm[i].kind]
+		}
+
+		a.add(size, func(a *assembler, pc uint16) ([]uint8, error) {
+			bytes := append([]uint8{}, code...)
+			for _, op := range imm {
+				b, err := a.encode(op.kind, op.expr, pc+uint16(size))
+				if err != nil {
+					return nil, err
+				}
+				bytes = append(bytes, b...)
+			}
+
+			return bytes, nil
+		})
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown instruction %s %s", mnemonic, strings.Join(operands, ","))
+}
+
+// encode evaluates an immediate, next is the address following the
+// instruction which relative jumps are relative to.
+func (a *assembler) encode(kind string, expr string, next uint16) ([]uint8, error) {
+	v, err := a.eval(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	low, high := -128, 255
+	switch kind {
+	case "a8":
+		if v >= 0xFF00 && v <= 0xFFFF {
+			v -= 0xFF00
+		}
+		low = 0
+	case "e8":
+		high = 127
+	case "rel8":
+		v -= int(next)
+		high = 127
+	case "n16", "a16":
+		low, high = -32768, 65535
+	}
+
+	if v < low || v > high {
+		return nil, fmt.Errorf("%s is out of range", expr)
+	}
+
+	if immediates[kind] == 2 {
+		return []uint8{uint8(v), uint8(v >> 8)}, nil
+	}
+
+	return []uint8{uint8(v)}, nil
+}
+
+// eval sums the numbers and labels of an expression.
+func (a *assembler) eval(expr string) (int, error) {
+	terms, ok := split(expr)
+	if !ok {
+		return 0, fmt.Errorf("invalid expression %q", expr)
+	}
+
+	sum := 0
+	for _, t := range terms {
+		v, err := parseNumber(t.text)
+		if err != nil {
+			label, ok := a.labels[t.text]
+			if !ok {
+				return 0, fmt.Errorf("undefined label %s", t.text)
+			}
+			v = label
+		}
+
+		if t.negative {
+			v = -v
+		}
+		sum += v
+	}
+
+	return sum, nil
+}
+
+type term struct {
+	negative bool
+	text     string
+}
+
+// split cuts an expression in its terms, checking they are numbers or
+// identifiers which do not name a register.
+func split(expr string) ([]term, bool) {
+	var terms []term
+
+	negative := false
+	if expr != "" && (expr[0] == '-' || expr[0] == '+') {
+		negative, expr = expr[0] == '-', expr[1:]
+	}
+
+	for {
+		i := strings.IndexAny(expr, "+-")
+		if i < 0 {
+			i = len(expr)
+		}
+
+		text := expr[:i]
+		if _, err := parseNumber(text); err != nil && (!isIdentifier(text) || reserved[strings.ToUpper(text)]) {
+			return nil, false
+		}
+		terms = append(terms, term{negative, text})
+
+		if i == len(expr) {
+			return terms, true
+		}
+		negative, expr = expr[i] == '-', expr[i+1:]
+	}
+}
+
+func parseNumber(text string) (int, error) {
+	base := 10
+	switch {
+	case strings.HasPrefix(text, "$"):
+		base, text = 16, text[1:]
+	case strings.HasPrefix(text, "0x"), strings.HasPrefix(text, "0X"):
+		base, text = 16, text[2:]
+	case strings.HasPrefix(text, "%"):
+		base, text = 2, text[1:]
+	}
+
+	v, err := strconv.ParseUint(text, base, 16)
+	return int(v), err
+}
+
+func isIdentifier(text string) bool {
+	for i, r := range text {
+		letter := r == '_' || r == '.' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !letter && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+
+	return text != ""
+}
+
+// reserved names cannot be used as labels, they are operands on their own.
+var reserved = map[string]bool{
+	"A": true, "B": true, "C": true, "D": true, "E": true, "H": true, "L": true,
+	"AF": true, "BC": true, "DE": true, "HL": true, "SP": true,
+	"NZ": true, "Z": true, "NC": true,
+}
+
+// immediates maps the placeholders of the opcode tables to their size, rel8
+// is the e8 of JR, given as a target address.
+var immediates = map[string]int{"n8": 1, "a8": 1, "e8": 1, "rel8": 1, "n16": 2, "a16": 2}
+
+type encoding struct {
+	code     []uint8
+	operands []string
+}
+
+type operand struct {
+	kind string
+	expr string
+}
+
+// encodings indexes the opcode tables by mnemonic.
+var encodings = func() map[string][]encoding {
+	m := map[string][]encoding{}
+	add := func(name string, code ...uint8) {
+		if name == "ILLEGAL" || name == "PREFIX CB" {
+			return
+		}
+
+		mnemonic, operands, _ := strings.Cut(name, " ")
+		e := encoding{code: code}
+		if operands != "" {
+			e.operands = strings.Split(operands, ",")
+		}
+		m[mnemonic] = append(m[mnemonic], e)
+	}
+
+	for i := 0; i < 256; i++ {
+		add(cpu.Opcode(uint8(i)).Name, uint8(i))
+		add(cpu.ExtendedOpcode(uint8(i)).Name, 0xCB, uint8(i))
+	}
+
+	return m
+}()
+
+// match checks the operands against those of the encoding, returning the
+// expressions given for its placeholders.
+func (e encoding) match(operands []string) ([]operand, bool) {
+	if len(operands) != len(e.operands) {
+		return nil, false
+	}
+
+	var imm []operand
+	for i, pattern := range e.operands {
+		s := strings.ReplaceAll(operands[i], " ", "")
+
+		kind := placeholder(pattern)
+		if kind == "" {
+			// RST vectors and bit numbers are matched by value
+			if n, err := parseNumber(pattern); err == nil {
+				if v, err := parseNumber(s); err != nil || v != n {
+					return nil, false
+				}
+			} else if !strings.EqualFold(s, pattern) {
+				return nil, false
+			}
+			continue
+		}
+
+		prefix, suffix, _ := strings.Cut(pattern, kind)
+		upper := strings.ToUpper(s)
+		if prefix == "SP+" && strings.HasPrefix(upper, "SP-") {
+			prefix = "SP"
+		}
+
+		if len(s) <= len(prefix)+len(suffix) || !strings.HasPrefix(upper, prefix) || !strings.HasSuffix(upper, suffix) {
+			return nil, false
+		}
+
+		expr := s[len(prefix) : len(s)-len(suffix)]
+		if _, ok := split(expr); !ok {
+			return nil, false
+		}
+		imm = append(imm, operand{kind, expr})
+	}
+
+	return imm, true
+}
+
+func placeholder(pattern string) string {
+	for _, kind := range []string{"n16", "a16", "n8", "a8", "e8"} {
+		if strings.Contains(pattern, kind) {
+			return kind
+		}
+	}
+
+	return ""
+}
+
+// alias rewrites the usual alternative spellings to the names of the opcode
+// tables.
+func alias(mnemonic string, operands []string) (string, []string) {
+	hl := ""
+	switch mnemonic {
+	case "LDI":
+		mnemonic, hl = "LD", "(HL+)"
+	case "LDD":
+		mnemonic, hl = "LD", "(HL-)"
+	case "STOP":
+		if len(operands) == 0 {
+			operands = []string{"0"}
+		}
+	}
+
+	for i, op := range operands {
+		switch strings.ToUpper(strings.ReplaceAll(op, " ", "")) {
+		case "(HLI)":
+			operands[i] = "(HL+)"
+		case "(HLD)":
+			operands[i] = "(HL-)"
+		case "($FF00+C)", "(0XFF00+C)":
+			operands[i] = "(C)"
+		case "(HL)":
+			if hl != "" {
+				operands[i] = hl
+			} else if mnemonic == "JP" {
+				operands[i] = "HL"
+			}
+		}
+	}
+
+	if mnemonic == "LDH" && len(operands) == 2 && (operands[0] == "(C)" || operands[1] == "(C)") {
+		mnemonic = "LD"
+	}
+
+	return mnemonic, operands
+}
+
+// splitOperands cuts the operand list on the commas outside of strings.
+func splitOperands(text string) []string {
+	var operands []string
+
+	start, quoted := 0, false
+	for i, r := range text {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			operands = append(operands, strings.TrimSpace(text[start:i]))
+			start = i + 1
+		}
+	}
+
+	if last := strings.TrimSpace(text[start:]); last != "" || len(operands) != 0 {
+		operands = append(operands, last)
+	}
+
+	return operands
+}
+
+func stripComment(text string) string {
+	quoted := false
+	for i, r := range text {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ';' && !quoted:
+			return text[:i]
+		}
+	}
+
+	return text
+}
+
+func unquote(operand string) (string, bool) {
+	if len(operand) < 2 || operand[0] != '"' {
+		return "", false
+	}
+
+	s, err := strconv.Unquote(operand)
+	return s, err == nil
+}
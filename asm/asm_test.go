@@ -0,0 +1,101 @@
+package asm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mrratatosk/oort-gb/disasm"
+)
+
+func TestAssemble(t *testing.T) {
+	src := `
+		org $0150
+	start:
+		LD A,$42        ; comment
+		LDH ($80),A
+		ld hl,message
+		ldi a,(hl)
+		LD ($FF00+C),A
+		JR NZ,start
+		CALL done
+		RST $38
+		BIT 7,(HL)
+		LD HL,SP-2
+	done: RET
+		org $4000
+	message:
+		db "Hi", 0, -1
+		dw start, $1234
+	`
+
+	sections, err := Assemble(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Section{
+		{0x0150, []uint8{
+			0x3E, 0x42,
+			0xE0, 0x80,
+			0x21, 0x00, 0x40,
+			0x2A,
+			0xE2,
+			0x20, 0xF5,
+			0xCD, 0x63, 0x01,
+			0xFF,
+			0xCB, 0x7E,
+			0xF8, 0xFE,
+			0xC9,
+		}},
+		{0x4000, []uint8{'H', 'i', 0x00, 0xFF, 0x50, 0x01, 0x34, 0x12}},
+	}
+
+	if len(sections) != len(want) {
+		t.Fatalf("got %d sections, want %d", len(sections), len(want))
+	}
+
+	for i, s := range sections {
+		if s.Origin != want[i].Origin || !bytes.Equal(s.Bytes, want[i].Bytes) {
+			t.Errorf("section %d = %04X % X, want %04X % X", i, s.Origin, s.Bytes, want[i].Origin, want[i].Bytes)
+		}
+	}
+}
+
+// Every instruction disassembled must assemble back to the same bytes.
+func TestRoundTrip(t *testing.T) {
+	for i := 0; i < 0x200; i++ {
+		code := []uint8{uint8(i), 0x12, 0x34}
+		if i >= 0x100 {
+			code = []uint8{0xCB, uint8(i)}
+		}
+
+		line := disasm.Disassemble(code, 0x100)[0]
+		if line.Text[:2] == "DB" || line.Text == "PREFIX CB" {
+			continue
+		}
+
+		sections, err := Assemble("org $0100\n" + line.Text)
+		if err != nil {
+			t.Errorf("%s: %v", line, err)
+			continue
+		}
+
+		if got := sections[0].Bytes; !bytes.Equal(got, line.Bytes) {
+			t.Errorf("%s assembled to % X", line, got)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for src, want := range map[string]string{
+		"LD A,$42\nJP nowhere":    "line 2: undefined label nowhere",
+		"LD A,$142":               "line 1: $142 is out of range",
+		"LD Q,A":                  "line 1: unknown instruction LD Q,A",
+		"x:\nx:":                  "line 2: label x already defined",
+		"JR far\norg $0200\nfar:": "line 1: far is out of range",
+	} {
+		if _, err := Assemble(src); err == nil || err.Error() != want {
+			t.Errorf("%q: got %v, want %s", src, err, want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package cpu
+
+// The ALU works on plain values. Each operation returns its result followed
+// by the carry, half carry and zero flags it computes; which of them an opcode
+// stores, and what N becomes, is given by its ZNHC description.
+
+func add8(a uint8, b uint8, carry bool) (uint8, bool, bool, bool) {
+	cy := bit(carry)
+	sum := uint(a) + uint(b) + cy
+	return uint8(sum), sum > 0xFF, uint(a&0xF)+uint(b&0xF)+cy > 0xF, uint8(sum) == 0
+}
+
+func sub8(a uint8, b uint8, carry bool) (uint8, bool, bool, bool) {
+	cy := int(bit(carry))
+	diff := int(a) - int(b) - cy
+	return uint8(diff), diff < 0, int(a&0xF)-int(b&0xF)-cy < 0, uint8(diff) == 0
+}
+
+// cp8 compares like SUB and leaves a unchanged.
+func cp8(a uint8, b uint8) (uint8, bool, bool, bool) {
+	_, cy, hc, z := sub8(a, b, false)
+	return a, cy, hc, z
+}
+
+func and8(a uint8, b uint8) (uint8, bool, bool, bool) {
+	r := a & b
+	return r, false, true, r == 0
+}
+
+func xor8(a uint8, b uint8) (uint8, bool, bool, bool) {
+	r := a ^ b
+	return r, false, false, r == 0
+}
+
+func or8(a uint8, b uint8) (uint8, bool, bool, bool) {
+	r := a | b
+	return r, false, false, r == 0
+}
+
+func inc8(v uint8) (uint8, bool, bool, bool) {
+	r := v + 1
+	return r, false, v&0xF == 0xF, r == 0
+}
+
+func dec8(v uint8) (uint8, bool, bool, bool) {
+	r := v - 1
+	return r, false, v&0xF == 0, r == 0
+}
+
+// daa8 adjusts a to BCD after an addition, or a subtraction when n is set,
+// using the half carry and carry that operation left.
+func daa8(a uint8, n bool, half bool, carry bool) (uint8, bool, bool, bool) {
+	if n {
+		if carry {
+			a -= 0x60
+		}
+		if half {
+			a -= 0x06
+		}
+	} else {
+		if carry || a > 0x99 {
+			a += 0x60
+			carry = true
+		}
+		if half || a&0x0F > 0x09 {
+			a += 0x06
+		}
+	}
+
+	return a, carry, false, a == 0
+}
+
+func rlc8(v uint8) (uint8, bool, bool, bool) {
+	r := v<<1 | v>>7
+	return r, v&0x80 != 0, false, r == 0
+}
+
+func rrc8(v uint8) (uint8, bool, bool, bool) {
+	r := v>>1 | v<<7
+	return r, v&0x01 != 0, false, r == 0
+}
+
+func rl8(v uint8, carry bool) (uint8, bool, bool, bool) {
+	r := v<<1 | uint8(bit(carry))
+	return r, v&0x80 != 0, false, r == 0
+}
+
+func rr8(v uint8, carry bool) (uint8, bool, bool, bool) {
+	r := v>>1 | uint8(bit(carry))<<7
+	return r, v&0x01 != 0, false, r == 0
+}
+
+func sla8(v uint8) (uint8, bool, bool, bool) {
+	r := v << 1
+	return r, v&0x80 != 0, false, r == 0
+}
+
+func sra8(v uint8) (uint8, bool, bool, bool) {
+	r := v>>1 | v&0x80
+	return r, v&0x01 != 0, false, r == 0
+}
+
+func swap8(v uint8) (uint8, bool, bool, bool) {
+	r := v<<4 | v>>4
+	return r, false, false, r == 0
+}
+
+func srl8(v uint8) (uint8, bool, bool, bool) {
+	r := v >> 1
+	return r, v&0x01 != 0, false, r == 0
+}
+
+// add16 is ADD HL, the carries come out of bits 11 and 15.
+func add16(a uint16, b uint16) (uint16, bool, bool) {
+	sum := uint(a) + uint(b)
+	return uint16(sum), sum > 0xFFFF, a&0xFFF+b&0xFFF > 0xFFF
+}
+
+// addOffset is ADD SP,e8 and LD HL,SP+e8: the offset is signed but the flags
+// come from the unsigned addition of the low bytes.
+func addOffset(sp uint16, e uint8) (uint16, bool, bool) {
+	return sp + uint16(int8(e)), sp&0xFF+uint16(e) > 0xFF, sp&0xF+uint16(e&0xF) > 0xF
+}
+
+func bit(b bool) uint {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,167 @@
+package cpu
+
+import "testing"
+
+// flags packs the carry, half carry and zero flags returned by the ALU like
+// refCPU stores them, N aside.
+func flags(cy bool, hc bool, z bool) uint8 {
+	return uint8(bit(z))<<7 | uint8(bit(hc))<<5 | uint8(bit(cy))<<4
+}
+
+func TestALU8(t *testing.T) {
+	ops := []func(a uint8, b uint8, carry bool) (uint8, bool, bool, bool){
+		func(a, b uint8, _ bool) (uint8, bool, bool, bool) { return add8(a, b, false) },
+		add8,
+		func(a, b uint8, _ bool) (uint8, bool, bool, bool) { return sub8(a, b, false) },
+		sub8,
+		func(a, b uint8, _ bool) (uint8, bool, bool, bool) { return and8(a, b) },
+		func(a, b uint8, _ bool) (uint8, bool, bool, bool) { return xor8(a, b) },
+		func(a, b uint8, _ bool) (uint8, bool, bool, bool) { return or8(a, b) },
+		func(a, b uint8, _ bool) (uint8, bool, bool, bool) { return cp8(a, b) },
+	}
+
+	ref := &refCPU{}
+	for i, op := range ops {
+		for a := 0; a < 0x100; a++ {
+			for b := 0; b < 0x100; b++ {
+				for _, carry := range []bool{false, true} {
+					ref.a, ref.f = uint8(a), flags(carry, false, false)
+					ref.alu(uint8(i), uint8(b))
+
+					r, cy, hc, z := op(uint8(a), uint8(b), carry)
+					if r != ref.a || flags(cy, hc, z) != ref.f&^refN {
+						t.Fatalf("op %d (%02X, %02X, %v) = %02X %02X, want %02X %02X", i, a, b, carry, r, flags(cy, hc, z), ref.a, ref.f&^refN)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestShifts(t *testing.T) {
+	ops := []func(v uint8, carry bool) (uint8, bool, bool, bool){
+		func(v uint8, _ bool) (uint8, bool, bool, bool) { return rlc8(v) },
+		func(v uint8, _ bool) (uint8, bool, bool, bool) { return rrc8(v) },
+		rl8,
+		rr8,
+		func(v uint8, _ bool) (uint8, bool, bool, bool) { return sla8(v) },
+		func(v uint8, _ bool) (uint8, bool, bool, bool) { return sra8(v) },
+		func(v uint8, _ bool) (uint8, bool, bool, bool) { return swap8(v) },
+		func(v uint8, _ bool) (uint8, bool, bool, bool) { return srl8(v) },
+	}
+
+	ref := &refCPU{}
+	for i, op := range ops {
+		for v := 0; v < 0x100; v++ {
+			for _, carry := range []bool{false, true} {
+				ref.f = flags(carry, false, false)
+				want := ref.rot(uint8(i), uint8(v))
+
+				r, cy, hc, z := op(uint8(v), carry)
+				if r != want || flags(cy, hc, z) != ref.f {
+					t.Fatalf("op %d (%02X, %v) = %02X %02X, want %02X %02X", i, v, carry, r, flags(cy, hc, z), want, ref.f)
+				}
+			}
+		}
+	}
+}
+
+// ripple adds a and b one bit at a time, as a chain of full adders would, and
+// returns the sum with the carry out of each bit. It checks the flags of the
+// ALU without sharing its arithmetic.
+func ripple(a uint16, b uint16, width uint) (sum uint16, carries uint16) {
+	carry := uint16(0)
+	for i := uint(0); i < width; i++ {
+		x, y := a>>i&1, b>>i&1
+		sum |= (x ^ y ^ carry) << i
+		carry = x&y | x&carry | y&carry
+		carries |= carry << i
+	}
+
+	return sum, carries
+}
+
+func TestIncDec(t *testing.T) {
+	for v := 0; v < 0x100; v++ {
+		sum, carries := ripple(uint16(v), 1, 8)
+		r, cy, hc, z := inc8(uint8(v))
+		if r != uint8(sum) || cy || hc != (carries&0x08 != 0) || z != (sum == 0) {
+			t.Fatalf("inc8(%02X) = %02X %v %v %v", v, r, cy, hc, z)
+		}
+
+		// v - 1 is v + 0xFF, a nibble borrow is the lack of a carry out of bit 3
+		sum, carries = ripple(uint16(v), 0xFF, 8)
+		r, cy, hc, z = dec8(uint8(v))
+		if r != uint8(sum) || cy || hc != (carries&0x08 == 0) || z != (sum == 0) {
+			t.Fatalf("dec8(%02X) = %02X %v %v %v", v, r, cy, hc, z)
+		}
+	}
+}
+
+func TestDAA(t *testing.T) {
+	ref := &refCPU{mem: [0x10000]uint8{0x27}}
+	for a := 0; a < 0x100; a++ {
+		for f := 0; f < 0x100; f += 0x10 {
+			ref.a, ref.f, ref.pc = uint8(a), uint8(f), 0
+			ref.step()
+
+			r, cy, hc, z := daa8(uint8(a), f&0x40 != 0, f&0x20 != 0, f&0x10 != 0)
+			if r != ref.a || flags(cy, hc, z) != ref.f&^refN {
+				t.Fatalf("daa8(%02X, F=%02X) = %02X %02X, want %02X %02X", a, f, r, flags(cy, hc, z), ref.a, ref.f&^refN)
+			}
+		}
+	}
+
+	// adding or subtracting BCD numbers then adjusting gives their BCD result
+	for x := 0; x < 100; x++ {
+		for y := 0; y < 100; y++ {
+			bx, by := uint8(x/10<<4|x%10), uint8(y/10<<4|y%10)
+
+			sum, cy, hc, _ := add8(bx, by, false)
+			r, cy, _, _ := daa8(sum, false, hc, cy)
+			if s := x + y; r != uint8(s%100/10<<4|s%10) || cy != (s >= 100) {
+				t.Fatalf("%d + %d = %02X carry %v", x, y, r, cy)
+			}
+
+			diff, cy, hc, _ := sub8(bx, by, false)
+			r, cy, _, _ = daa8(diff, true, hc, cy)
+			if d := (x - y + 100) % 100; r != uint8(d/10<<4|d%10) || cy != (x < y) {
+				t.Fatalf("%d - %d = %02X borrow %v", x, y, r, cy)
+			}
+		}
+	}
+}
+
+func TestAdd16(t *testing.T) {
+	ref := &refCPU{}
+	for _, hi := range []uint16{0x0000, 0x0F00, 0x7F00, 0xC000, 0xFF00} {
+		for lo := uint16(0); lo < 0x100; lo++ {
+			sp := hi | lo
+			for e := 0; e < 0x100; e++ {
+				ref.sp = sp
+				want := ref.addSP(uint8(e))
+
+				r, cy, hc := addOffset(sp, uint8(e))
+				if r != want || flags(cy, hc, false) != ref.f {
+					t.Fatalf("addOffset(%04X, %02X) = %04X %02X, want %04X %02X", sp, e, r, flags(cy, hc, false), want, ref.f)
+				}
+			}
+		}
+	}
+
+	// every pair of low 12 bits, which the half carry depends on, with the
+	// high nibbles going through all their pairs along the way
+	i := uint16(0)
+	for a := uint16(0); a < 0x1000; a++ {
+		for b := uint16(0); b < 0x1000; b++ {
+			x, y := i<<12|a, i>>4<<12|b
+			i++
+
+			sum, carries := ripple(x, y, 16)
+			r, cy, hc := add16(x, y)
+			if r != sum || cy != (carries&0x8000 != 0) || hc != (carries&0x0800 != 0) {
+				t.Fatalf("add16(%04X, %04X) = %04X %v %v", x, y, r, cy, hc)
+			}
+		}
+	}
+}
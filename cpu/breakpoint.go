@@ -0,0 +1,161 @@
+package cpu
+
+import "fmt"
+
+type BreakKind uint8
+
+const (
+	// BreakPC pauses before the instruction at Address is fetched.
+	BreakPC BreakKind = iota
+	// BreakOpcode pauses before any instruction with the given opcode.
+	BreakOpcode
+	// WatchRead and WatchWrite pause after the instruction accessing Address.
+	WatchRead
+	WatchWrite
+)
+
+func (k BreakKind) String() string {
+	return [...]string{"pc", "opcode", "read", "write"}[k]
+}
+
+type Breakpoint struct {
+	Kind    BreakKind
+	Address uint16
+	// Opcode is matched by BreakOpcode, with Extended the CB prefixed one.
+	Opcode   uint8
+	Extended bool
+	// Cond optionally filters watchpoints on the value read or written.
+	Cond func(value uint8) bool
+}
+
+// Hit reports the breakpoint that paused the CPU. PC is the address of the
+// instruction that triggered it, Address and Value those of the access, or of
+// the opcode for BreakPC and BreakOpcode.
+type Hit struct {
+	ID         int
+	Breakpoint Breakpoint
+	PC         uint16
+	Address    uint16
+	Value      uint8
+}
+
+func (h Hit) String() string {
+	return fmt.Sprintf("breakpoint %d (%s) hit at 0x%04X: [0x%04X] = 0x%02X", h.ID, h.Breakpoint.Kind, h.PC, h.Address, h.Value)
+}
+
+type breakpoint struct {
+	id int
+	Breakpoint
+}
+
+// AddBreakpoint installs b and returns the id to remove it with.
+func (c *Cpu) AddBreakpoint(b Breakpoint) int {
+	c.nextBreakpoint++
+	c.breakpoints = append(c.breakpoints, breakpoint{c.nextBreakpoint, b})
+
+	return c.nextBreakpoint
+}
+
+func (c *Cpu) RemoveBreakpoint(id int) {
+	for i, b := range c.breakpoints {
+		if b.id == id {
+			c.breakpoints = append(c.breakpoints[:i], c.breakpoints[i+1:]...)
+			return
+		}
+	}
+}
+
+// Break returns the breakpoint the CPU is paused on, nil while it runs.
+func (c Cpu) Break() *Hit {
+	if !c.paused {
+		return nil
+	}
+
+	return c.hit
+}
+
+// Resume lets a paused CPU go on, it does nothing while the CPU runs so that a
+// watchpoint hit waiting for the end of its instruction is kept. A PC or
+// opcode breakpoint on the current instruction is stepped over, after a
+// watchpoint they still have to be checked.
+func (c *Cpu) Resume() {
+	if !c.paused {
+		return
+	}
+
+	watched := c.hit != nil && c.hit.Breakpoint.Kind >= WatchRead
+	c.stepOver = c.hit != nil && !watched
+	c.hit = nil
+	c.paused = false
+
+	if watched {
+		c.checkBreakpoints()
+	}
+}
+
+// boundary reports whether the next cycle starts a new instruction.
+func (c *Cpu) boundary() bool {
+	return c.step >= len(c.ins.Steps) && c.prefix == nil && c.fault == nil && !c.halted && !c.stopped
+}
+
+// checkBreakpoints runs at the end of a cycle. PC and opcode breakpoints are
+// checked as soon as an instruction is over, a watchpoint hit in the middle of
+// an instruction pauses once it is over.
+func (c *Cpu) checkBreakpoints() {
+	if c.paused || !c.boundary() {
+		return
+	}
+
+	if c.hit == nil {
+		c.hit = c.matchBreakpoint()
+	}
+
+	c.paused = c.hit != nil
+}
+
+// fetchBreak pauses on a PC or opcode breakpoint right before the opcode
+// fetch, which the boundary checks miss for the first instruction run. The
+// instruction Resume was called on is stepped over.
+func (c *Cpu) fetchBreak() bool {
+	if c.stepOver {
+		c.stepOver = false
+		return false
+	}
+
+	c.hit = c.matchBreakpoint()
+	c.paused = c.hit != nil
+
+	return c.paused
+}
+
+// matchBreakpoint returns the PC or opcode breakpoint set on the instruction
+// at PC, if any.
+func (c *Cpu) matchBreakpoint() *Hit {
+	pc := c.Registers.PC
+	code, next := c.mem.Read(pc), c.mem.Read(pc+1)
+	_, prefixed := c.extensions[code]
+
+	for _, b := range c.breakpoints {
+		if (b.Kind == BreakPC && b.Address == pc) ||
+			(b.Kind == BreakOpcode && !b.Extended && b.Opcode == code) ||
+			(b.Kind == BreakOpcode && b.Extended && prefixed && b.Opcode == next) {
+			return &Hit{ID: b.id, Breakpoint: b.Breakpoint, PC: pc, Address: pc, Value: code}
+		}
+	}
+
+	return nil
+}
+
+// watch records the first watchpoint matching a memory access.
+func (c *Cpu) watch(kind BreakKind, address uint16, value uint8) {
+	if c.hit != nil {
+		return
+	}
+
+	for _, b := range c.breakpoints {
+		if b.Kind == kind && b.Address == address && (b.Cond == nil || b.Cond(value)) {
+			c.hit = &Hit{ID: b.id, Breakpoint: b.Breakpoint, PC: c.origin, Address: address, Value: value}
+			return
+		}
+	}
+}
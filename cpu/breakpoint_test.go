@@ -0,0 +1,80 @@
+package cpu
+
+import "testing"
+
+// runUntilBreak ticks c until it pauses, at most n cycles.
+func runUntilBreak(c *Cpu, n int) *Hit {
+	for i := 0; i < n && c.Break() == nil; i++ {
+		tick(c, 1)
+	}
+
+	return c.Break()
+}
+
+func TestBreakpoints(t *testing.T) {
+	// LD A,$42 / LD ($C000),A / INC A / LD ($C000),A / CB 37 (SWAP A) / JR -2
+	c, mem := newTestCpu(0x100, 0x3E, 0x42, 0xEA, 0x00, 0xC0, 0x3C, 0xEA, 0x00, 0xC0, 0xCB, 0x37, 0x18, 0xFE)
+
+	pc := c.AddBreakpoint(Breakpoint{Kind: BreakPC, Address: 0x102})
+	write := c.AddBreakpoint(Breakpoint{Kind: WatchWrite, Address: 0xC000, Cond: func(v uint8) bool { return v == 0x43 }})
+	swap := c.AddBreakpoint(Breakpoint{Kind: BreakOpcode, Opcode: 0x37, Extended: true})
+
+	hit := runUntilBreak(c, 100)
+	if hit == nil || hit.ID != pc || c.Registers.PC != 0x102 || mem.Read(0xC000) != 0 {
+		t.Fatalf("got %v at %04X, want breakpoint %d at 0102", hit, c.Registers.PC, pc)
+	}
+
+	c.Resume()
+	hit = runUntilBreak(c, 100)
+	if hit == nil || hit.ID != write || hit.PC != 0x106 || hit.Value != 0x43 || c.Registers.PC != 0x109 {
+		t.Fatalf("got %v at %04X, want watchpoint %d", hit, c.Registers.PC, write)
+	}
+
+	c.Resume()
+	hit = runUntilBreak(c, 100)
+	if hit == nil || hit.ID != swap || c.Registers.PC != 0x109 || c.Registers.A != 0x43 {
+		t.Fatalf("got %v at %04X, want breakpoint %d", hit, c.Registers.PC, swap)
+	}
+
+	c.RemoveBreakpoint(swap)
+	c.Resume()
+	if hit = runUntilBreak(c, 100); hit != nil {
+		t.Errorf("got %v after removing the last breakpoint", hit)
+	}
+}
+
+// A breakpoint on the PC the CPU starts at has no boundary before it.
+func TestBreakpointOnFirstInstruction(t *testing.T) {
+	// INC A / JR -2
+	c, _ := newTestCpu(0x100, 0x3C, 0x18, 0xFD)
+	id := c.AddBreakpoint(Breakpoint{Kind: BreakPC, Address: 0x100})
+
+	hit := runUntilBreak(c, 10)
+	if hit == nil || hit.ID != id || c.Registers.PC != 0x100 || c.Registers.A != 0 {
+		t.Fatalf("got %v at %04X with A=%d, want breakpoint %d at 0100", hit, c.Registers.PC, c.Registers.A, id)
+	}
+
+	c.Resume()
+	hit = runUntilBreak(c, 10)
+	if hit == nil || hit.ID != id || c.Registers.PC != 0x100 || c.Registers.A != 1 {
+		t.Fatalf("got %v at %04X with A=%d after a loop, want breakpoint %d", hit, c.Registers.PC, c.Registers.A, id)
+	}
+}
+
+// Run resumes the CPU before each slice of cycles, a watchpoint hit in the
+// middle of an instruction must survive until the instruction is over.
+func TestWatchpointAcrossResume(t *testing.T) {
+	// LD SP,$D000 / CALL $0010
+	c, _ := newTestCpu(0x100, 0x31, 0x00, 0xD0, 0xCD, 0x10, 0x00)
+	id := c.AddBreakpoint(Breakpoint{Kind: WatchWrite, Address: 0xCFFF})
+
+	for i := 0; i < 20 && c.Break() == nil; i++ {
+		c.Resume()
+		tick(c, 1)
+	}
+
+	hit := c.Break()
+	if hit == nil || hit.ID != id || hit.PC != 0x103 || c.Registers.PC != 0x0010 {
+		t.Fatalf("got %v at %04X, want watchpoint %d", hit, c.Registers.PC, id)
+	}
+}
@@ -0,0 +1,94 @@
+package cpu
+
+import "fmt"
+
+// Frame is an entry of the shadow call stack, pushed by CALL, RST and
+// interrupt dispatch. SP points at the return address on the stack.
+type Frame struct {
+	Caller    uint16
+	Target    uint16
+	Return    uint16
+	SP        uint16
+	Interrupt bool
+}
+
+func (f Frame) String() string {
+	if f.Interrupt {
+		return fmt.Sprintf("0x%04X interrupting 0x%04X (sp 0x%04X)", f.Target, f.Caller, f.SP)
+	}
+
+	return fmt.Sprintf("0x%04X called from 0x%04X (sp 0x%04X)", f.Target, f.Caller, f.SP)
+}
+
+// MismatchedReturn describes a return that does not pop the return address of
+// the frame on top of the shadow stack, as when a game pushes its own address
+// to jump through RET or overwrites the stack. Expected is nil when no frame
+// was pushed at SP.
+type MismatchedReturn struct {
+	PC       uint16
+	SP       uint16
+	Target   uint16
+	Expected *Frame
+}
+
+// Backtrace returns the shadow call stack, innermost frame first.
+func (c Cpu) Backtrace() []Frame {
+	frames := make([]Frame, len(c.frames))
+	for i, f := range c.frames {
+		frames[len(frames)-1-i] = f
+	}
+
+	return frames
+}
+
+// OnMismatchedReturn registers h to run on every return the shadow stack
+// does not expect.
+func (c *Cpu) OnMismatchedReturn(h func(m MismatchedReturn)) {
+	c.mismatched = append(c.mismatched, h)
+}
+
+// enter pushes a frame once the return address is on the stack and PC holds
+// the target. Frames at or below SP are stale, their stack was reused.
+func (c *Cpu) enter(interrupt bool) {
+	sp := c.Registers.SP
+
+	n := len(c.frames)
+	for n > 0 && c.frames[n-1].SP <= sp {
+		n--
+	}
+
+	c.frames = append(c.frames[:n], Frame{
+		Caller:    c.origin,
+		Target:    c.Registers.PC,
+		Return:    uint16(c.mem.Read(sp+1))<<8 | uint16(c.mem.Read(sp)),
+		SP:        sp,
+		Interrupt: interrupt,
+	})
+}
+
+// leave pops the frame matching a return once PC has been popped. Frames
+// below it were abandoned by code discarding its return address.
+func (c *Cpu) leave() {
+	sp, target := c.Registers.SP-2, c.Registers.PC
+
+	n := len(c.frames)
+	for n > 0 && c.frames[n-1].SP < sp {
+		n--
+	}
+	c.frames = c.frames[:n]
+
+	m := MismatchedReturn{PC: c.origin, SP: sp, Target: target}
+	if n > 0 && c.frames[n-1].SP == sp {
+		f := c.frames[n-1]
+		c.frames = c.frames[:n-1]
+
+		if f.Return == target {
+			return
+		}
+		m.Expected = &f
+	}
+
+	for _, h := range c.mismatched {
+		h(m)
+	}
+}
@@ -0,0 +1,44 @@
+package cpu
+
+import "testing"
+
+func TestCallStack(t *testing.T) {
+	// CALL $0200 / HALT
+	c, mem := newTestCpu(0x100, 0xCD, 0x00, 0x02, 0x76)
+	// RST $08 / LD HL,$0150 / PUSH HL / RET
+	mem.WriteRange(0x200, []uint8{0xCF, 0x21, 0x50, 0x01, 0xE5, 0xC9})
+	// RET
+	mem.WriteRange(0x08, []uint8{0xC9})
+	// RET
+	mem.WriteRange(0x150, []uint8{0xC9})
+
+	c.Registers.SP = 0xFFFE
+
+	var mismatches []MismatchedReturn
+	c.OnMismatchedReturn(func(m MismatchedReturn) { mismatches = append(mismatches, m) })
+
+	tick(c, 6+4)
+	frames := c.Backtrace()
+	want := []Frame{
+		{Caller: 0x200, Target: 0x08, Return: 0x201, SP: 0xFFFA},
+		{Caller: 0x100, Target: 0x200, Return: 0x103, SP: 0xFFFC},
+	}
+	if len(frames) != 2 || frames[0] != want[0] || frames[1] != want[1] {
+		t.Fatalf("backtrace = %v, want %v", frames, want)
+	}
+
+	// RET / LD HL / PUSH HL / RET jumping to $0150 through the stack
+	tick(c, 4+3+4+4)
+	if len(c.Backtrace()) != 1 || c.Registers.PC != 0x150 {
+		t.Fatalf("backtrace = %v at %04X", c.Backtrace(), c.Registers.PC)
+	}
+	if len(mismatches) != 1 || mismatches[0] != (MismatchedReturn{PC: 0x205, SP: 0xFFFA, Target: 0x150}) {
+		t.Fatalf("mismatches = %+v", mismatches)
+	}
+
+	// RET from $0150 pops the frame of the CALL
+	tick(c, 4)
+	if len(mismatches) != 1 || len(c.Backtrace()) != 0 || c.Registers.PC != 0x103 {
+		t.Fatalf("backtrace = %v, mismatches = %v at %04X", c.Backtrace(), mismatches, c.Registers.PC)
+	}
+}
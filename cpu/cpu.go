@@ -1,99 +1,254 @@
 package cpu
 
 import (
+	"fmt"
+	"io"
 	"sync"
 
-	"github.com/mrratatosk/oort-framework/memory"
-	"github.com/mrratatosk/oort-framework/processor"
+	"github.com/mrratatosk/oort-framework/tools"
 )
 
 type Cpu struct {
-	mem          *MEM
-	currentCycle uint
-	processor.ProcessorUnit[uint16, uint8]
+	mem            Bus
+	ins            Instruction
+	step           int
+	z              uint8
+	w              uint8
+	ime            bool
+	imeScheduled   bool
+	halted         bool
+	haltBug        bool
+	stopped        bool
+	prefix         *[256]Instruction
+	fault          *CrashError
+	bank           func(address uint16) uint16
+	recent         []HistoryEntry
+	recorded       uint
+	model          Model
+	doubleSpeed    bool
+	speedArmed     bool
+	switching      int
+	oamScan        OAMScan
+	instructions   *[256]Instruction
+	extensions     map[uint8]*[256]Instruction
+	tracer         io.Writer
+	before         []Hook
+	after          []Hook
+	current        *Execution
+	origin         uint16
+	fetched        [3]uint8
+	fetchedLen     int
+	breakpoints    []breakpoint
+	nextBreakpoint int
+	hit            *Hit
+	paused         bool
+	stepOver       bool
+	frames         []Frame
+	mismatched     []func(m MismatchedReturn)
+	Registers      Registers
 }
 
-func New(mem *memory.Memory[uint16, uint8]) *Cpu {
+// Bus is the address space seen by the CPU, a flat memory or an MMU.
+type Bus interface {
+	Read(address uint16) uint8
+	Write(address uint16, value uint8)
+}
+
+func New(mem Bus) *Cpu {
 	return &Cpu{
 		mem:          mem,
-		currentCycle: 0,
-		ProcessorUnit: processor.ProcessorUnit[uint16, uint8]{
-			InstructionSet: instructionSet(),
-			ExtensionSet:   extensionSet(),
-			Registers:      registers(),
-		},
+		instructions: instructionSet,
+		extensions:   map[uint8]*[256]Instruction{0xCB: extensionSet},
+		bank:         FixedBanks,
+		recent:       make([]HistoryEntry, defaultHistory),
 	}
 }
 
+// ClockDivider is 4 clocks per M-cycle, 2 in CGB double speed mode.
 func (c Cpu) ClockDivider() uint8 {
+	if c.doubleSpeed {
+		return 2
+	}
+
 	return 4
 }
 
+// Tick runs a single M-cycle: either the next step of the instruction in
+// flight, or the fetch of a new opcode together with its first step. A panic
+// is turned into a *CrashError stopping the CPU, see Err.
 func (c *Cpu) Tick(wg *sync.WaitGroup) {
-	if c.currentCycle == 0 {
-		opcode := c.fetch()
-		ins, params := c.decode(opcode)
-		c.currentCycle = c.execute(ins, params)
+	defer func() {
+		if r := recover(); r != nil {
+			c.crash(fmt.Errorf("panic: %v", r))
+		}
+		wg.Done()
+	}()
+
+	switch {
+	case c.fault != nil:
+	case c.step < len(c.ins.Steps):
+		c.execute()
+	case c.paused:
+	case c.switching > 0:
+		c.switching--
+	case c.stopped:
+		c.stopped = !c.joypadLineLow()
+	case c.halted:
+		// waking up from HALT costs one extra cycle, whatever IME is
+		c.halted = c.pendingInterrupts() == 0
+	case c.prefix == nil && c.ime && c.pendingInterrupts() != 0:
+		c.ime = false
+		c.imeScheduled = false
+		c.origin = c.Registers.PC
+		c.load(interruptDispatch)
+	case c.prefix == nil && len(c.breakpoints) != 0 && c.fetchBreak():
+	default:
+		// IME set by EI is only checked at the boundary after the next instruction
+		if c.imeScheduled {
+			c.ime = true
+			c.imeScheduled = false
+		}
+
+		if c.tracer != nil && c.prefix == nil {
+			c.trace()
+		}
+
+		if c.prefix == nil {
+			c.origin = c.Registers.PC
+			c.fetchedLen = 0
+
+			if c.hooked() {
+				c.begin()
+			}
+		}
+
+		c.load(c.decode(c.fetch()))
+	}
+
+	if len(c.breakpoints) != 0 {
+		c.checkBreakpoints()
+	}
+}
+
+func (c *Cpu) read(address uint16) uint8 {
+	value := c.mem.Read(address)
+	if len(c.breakpoints) != 0 {
+		c.watch(WatchRead, address, value)
+	}
+
+	return value
+}
+
+func (c *Cpu) write(address uint16, value uint8) {
+	if len(c.breakpoints) != 0 {
+		c.watch(WatchWrite, address, value)
+	}
+
+	c.mem.Write(address, value)
+}
+
+func (c *Cpu) fetch() uint8 {
+	value := c.read(c.Registers.PC)
+	c.collect(value)
+
+	if c.haltBug {
+		c.haltBug = false
 	} else {
-		c.currentCycle--
+		c.Registers.PC++
+	}
+
+	return value
+}
+
+func (c *Cpu) decode(opCode uint8) Instruction {
+	set := c.instructions
+	if c.prefix != nil {
+		set = c.prefix
+		c.prefix = nil
 	}
 
-	wg.Done()
+	return set[opCode]
 }
 
-func (c Cpu) fetch() uint8 {
-	return c.mem.Read(c.Registers.Get16("PC").Value)
+func (c *Cpu) load(ins Instruction) {
+	c.ins = ins
+	c.step = 0
+	c.execute()
 }
 
-func (c Cpu) decode(opCode uint8) (processor.Instruction[uint16, uint8], []uint8) {
-	set := c.InstructionSet
-	code := opCode
-	if val, ok := c.ExtensionSet[uint(code)]; ok {
-		set = val
-		code = c.mem.Read(c.Registers.Get16("PC").Inc().Value)
+func (c *Cpu) execute() {
+	step := c.ins.Steps[c.step]
+	c.step++
+
+	if step != nil {
+		step(c)
 	}
 
-	nextIns := set[uint(code)]
+	if c.current != nil {
+		c.current.Cycles += 4
+	}
 
-	params := make([]uint8, nextIns.Params)
-	p := uint(0)
-	for p < nextIns.Params {
-		params[0] = c.mem.Read(c.Registers.Get16("PC").Inc().Value)
-		p++
+	if c.step == len(c.ins.Steps) && c.prefix == nil {
+		if c.ins.Length != 0 && len(c.recent) != 0 {
+			c.record()
+		}
+		if c.current != nil {
+			c.end()
+		}
 	}
+}
 
-	c.Registers.Get16("PC").Inc()
-	return nextIns, params
+// skip drops the remaining steps of the instruction in flight, this is how
+// conditional instructions end early when their branch is not taken.
+func (c *Cpu) skip() {
+	c.step = len(c.ins.Steps)
 }
 
-func (c Cpu) execute(ins processor.Instruction[uint16, uint8], params []uint8) uint {
-	ins.Callback(c.ProcessorUnit, *c.mem, params...)
-	return ins.Cycle / 4
+func (c *Cpu) wz() uint16 {
+	return uint16(c.w)<<8 | uint16(c.z)
 }
 
-type Flag struct {
-	name  Flags
-	value bool
+func getFlag(c *Cpu, flag Flags) bool {
+	return tools.Bit8(c.Registers.F, uint(flag))
 }
 
-func getFlag(c CPU, flag Flags) bool {
-	return c.Registers.Get8("F").Bit(uint8(flag))
+// flagEffects is the compiled form of the ZNHC flags of an opcode: the bits
+// of F kept as they are, the ones forced to 1 and the computed ones.
+type flagEffects struct {
+	keep     uint8
+	set      uint8
+	computed uint8
 }
 
-func setFlag(c CPU, flag Flags, value bool) {
-	if value {
-		c.Registers.Get8("F").BitSet(uint8(flag))
-	} else {
-		c.Registers.Get8("F").BitClear(uint8(flag))
+func parseFlags(spec string) flagEffects {
+	var f flagEffects
+	for i, flag := range []Flags{Z, N, H, C} {
+		bit := uint8(1) << flag
+		switch spec[i] {
+		case '-':
+			f.keep |= bit
+		case '1':
+			f.set |= bit
+		case '0':
+		default:
+			f.computed |= bit
+		}
 	}
+
+	return f
 }
 
-func setFlags(c CPU, flags ...Flag) {
-	for _, flag := range flags {
-		setFlag(c, flag.name, flag.value)
+func applyFlags(c *Cpu, f flagEffects, z bool, hc bool, cy bool) {
+	computed := uint8(0)
+	if z {
+		computed |= 1 << Z
+	}
+	if hc {
+		computed |= 1 << H
+	}
+	if cy {
+		computed |= 1 << C
 	}
-}
 
-func setAllFlags(c CPU, cy bool, hc bool, z bool, n bool) {
-	setFlags(c, Flag{C, cy}, Flag{H, hc}, Flag{Z, z}, Flag{N, n})
+	c.Registers.F = c.Registers.F&f.keep | f.set | computed&f.computed
 }
@@ -0,0 +1,36 @@
+package cpu
+
+import (
+	"sync"
+
+	"github.com/mrratatosk/oort-framework/memory"
+	"github.com/mrratatosk/oort-gb/mmu"
+)
+
+// newTestCpu loads program at pc, with the stack at the top of WRAM.
+func newTestCpu(pc uint16, program ...uint8) (*Cpu, *memory.Memory[uint16, uint8]) {
+	mem := memory.NewMemory[uint16, uint8](0x10000)
+	mem.WriteRange(pc, program)
+
+	c := New(mem)
+	c.Registers.PC, c.Registers.SP = pc, 0xD000
+
+	return c, mem
+}
+
+// onMMU moves c onto an MMU over mem, for the tests mapping IO handlers.
+func onMMU(c *Cpu, mem *memory.Memory[uint16, uint8]) *mmu.MMU {
+	bus := mmu.New(mem)
+	c.mem = bus
+
+	return bus
+}
+
+// tick runs n M-cycles.
+func tick(c *Cpu, n int) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		c.Tick(&wg)
+	}
+}
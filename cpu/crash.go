@@ -0,0 +1,118 @@
+package cpu
+
+import (
+	"fmt"
+	"strings"
+)
+
+// stackDump is the number of bytes from SP included in crash reports.
+const stackDump = 16
+
+// CrashError is a CPU fault together with the state needed to report it.
+// Cause is a *LockupError, or the error a panic was recovered from.
+type CrashError struct {
+	Cause     error
+	Opcode    uint8
+	PC        uint16
+	Bank      uint16
+	Registers Registers
+	// History lists the last instructions executed, oldest first.
+	History   []HistoryEntry
+	Stack     []uint8
+	Backtrace []Frame
+}
+
+func (e *CrashError) Error() string {
+	return fmt.Sprintf("cpu crashed at %02X:%04X (opcode 0x%02X): %v", e.Bank, e.PC, e.Opcode, e.Cause)
+}
+
+func (e *CrashError) Unwrap() error {
+	return e.Cause
+}
+
+// Report formats the crash for a human, as a host would display or log it.
+func (e *CrashError) Report() string {
+	var b strings.Builder
+	r := e.Registers
+
+	fmt.Fprintln(&b, e.Error())
+	fmt.Fprintf(&b, "A:%02X F:%02X B:%02X C:%02X D:%02X E:%02X H:%02X L:%02X SP:%04X PC:%04X\n",
+		r.A, r.F, r.B, r.C, r.D, r.E, r.H, r.L, r.SP, r.PC)
+
+	fmt.Fprintf(&b, "stack:")
+	for _, v := range e.Stack {
+		fmt.Fprintf(&b, " %02X", v)
+	}
+	fmt.Fprintln(&b)
+
+	fmt.Fprintln(&b, "backtrace:")
+	for _, f := range e.Backtrace {
+		fmt.Fprintf(&b, "  %s\n", f)
+	}
+
+	fmt.Fprintln(&b, "history:")
+	for _, h := range e.History {
+		fmt.Fprintf(&b, "  %s\n", h)
+	}
+
+	return b.String()
+}
+
+// Err returns the *CrashError that stopped the CPU, nil while it runs.
+func (c Cpu) Err() error {
+	if c.fault == nil {
+		return nil
+	}
+
+	return c.fault
+}
+
+// FixedBanks maps ROM0 to bank 0 and ROMX to bank 1, any other address is
+// reported as bank 0. It is the mapping of a cartridge without mapper.
+func FixedBanks(address uint16) uint16 {
+	if address >= 0x4000 && address < 0x8000 {
+		return 1
+	}
+
+	return 0
+}
+
+// SetBanks sets how crash reports resolve the ROM bank mapped at an address.
+func (c *Cpu) SetBanks(bank func(address uint16) uint16) {
+	c.bank = bank
+}
+
+// crash stops the CPU on the instruction in flight. It may be called with the
+// panic of a bus read, memory and banks are read without letting a second
+// panic escape: what cannot be read is left out of the report.
+func (c *Cpu) crash(cause error) {
+	e := &CrashError{
+		Cause:     cause,
+		PC:        c.origin,
+		Registers: c.Registers,
+		History:   c.History(),
+		Backtrace: c.Backtrace(),
+	}
+
+	safely(func() { e.Opcode = c.mem.Read(c.origin) })
+	safely(func() { e.Bank = c.bank(c.origin) })
+	for i := uint16(0); i < stackDump; i++ {
+		if !safely(func() { e.Stack = append(e.Stack, c.mem.Read(c.Registers.SP+i)) }) {
+			break
+		}
+	}
+
+	c.fault = e
+}
+
+// safely runs f, reporting whether it returned rather than panicked.
+func safely(f func()) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	f()
+	return true
+}
@@ -0,0 +1,109 @@
+package cpu
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mrratatosk/oort-framework/memory"
+)
+
+func TestCrash(t *testing.T) {
+	// LD A,$42 / PUSH AF / CALL $4000 ... $4000: INC A / ILLEGAL
+	c, mem := newTestCpu(0x100, 0x3E, 0x42, 0xF5, 0xCD, 0x00, 0x40)
+	mem.WriteRange(0x4000, []uint8{0x3C, 0xDD})
+	tick(c, 100)
+
+	var crash *CrashError
+	var lockup *LockupError
+	if !errors.As(c.Err(), &crash) || !errors.As(c.Err(), &lockup) {
+		t.Fatalf("Err() = %v", c.Err())
+	}
+
+	if crash.PC != 0x4001 || crash.Opcode != 0xDD || crash.Bank != 1 || crash.Registers.A != 0x43 {
+		t.Errorf("crash = %v, A = %02X", crash, crash.Registers.A)
+	}
+
+	history := []uint16{0x100, 0x102, 0x103, 0x4000}
+	if len(crash.History) != len(history) {
+		t.Fatalf("history = %v", crash.History)
+	}
+	for i, pc := range history {
+		if crash.History[i].PC != pc {
+			t.Errorf("history %d at %04X, want %04X", i, crash.History[i].PC, pc)
+		}
+	}
+	if b := crash.History[2].Bytes(); len(b) != 3 || b[0] != 0xCD || b[2] != 0x40 {
+		t.Errorf("CALL bytes = % X", b)
+	}
+
+	if s := crash.Stack; s[0] != 0x06 || s[1] != 0x01 || s[3] != 0x42 {
+		t.Errorf("stack = % X", s)
+	}
+
+	if !strings.Contains(crash.Report(), "0x4000 called from 0x0103") {
+		t.Errorf("report:\n%s", crash.Report())
+	}
+}
+
+func TestCrashOnPanic(t *testing.T) {
+	c, _ := newTestCpu(0x0000)
+	c.BeforeInstruction(func(e Execution) {
+		if e.PC == 0x0002 {
+			panic("boom")
+		}
+	})
+	tick(c, 10)
+
+	var crash *CrashError
+	if !errors.As(c.Err(), &crash) || crash.PC != 0x0002 || crash.Cause.Error() != "panic: boom" || len(crash.History) != 2 {
+		t.Errorf("Err() = %v", c.Err())
+	}
+}
+
+// faultyBus panics on reads from 0xE000 on, as an unmapped region could.
+type faultyBus struct {
+	*memory.Memory[uint16, uint8]
+}
+
+func (b faultyBus) Read(address uint16) uint8 {
+	if address >= 0xE000 {
+		panic(fmt.Sprintf("read from 0x%04X", address))
+	}
+
+	return b.Memory.Read(address)
+}
+
+func TestCrashOnBusPanic(t *testing.T) {
+	// LD A,(HL)
+	c, mem := newTestCpu(0x100, 0x7E)
+	c.mem = faultyBus{mem}
+	c.Registers.SP = 0xFFF8
+	c.Registers.SetHL(0xE000)
+	tick(c, 3)
+
+	var crash *CrashError
+	if !errors.As(c.Err(), &crash) || crash.Cause.Error() != "panic: read from 0xE000" || crash.Opcode != 0x7E || len(crash.Stack) != 0 {
+		t.Errorf("Err() = %v", c.Err())
+	}
+}
+
+// The opcode fetch does not move PC during the HALT bug, the lockup is still
+// reported at the illegal opcode.
+func TestLockupAfterHaltBug(t *testing.T) {
+	// HALT / illegal $D3
+	c, mem := newTestCpu(0x100, 0x76, 0xD3)
+	mem.Write(InterruptEnable, 0x01)
+	mem.Write(InterruptFlag, 0x01)
+	tick(c, 3)
+
+	var crash *CrashError
+	var lockup *LockupError
+	if !errors.As(c.Err(), &crash) || !errors.As(c.Err(), &lockup) {
+		t.Fatalf("Err() = %v", c.Err())
+	}
+	if lockup.PC != 0x101 || crash.PC != 0x101 || lockup.Opcode != 0xD3 {
+		t.Errorf("lockup %v, crash at %04X", lockup, crash.PC)
+	}
+}
@@ -0,0 +1,102 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/mrratatosk/oort-framework/memory"
+)
+
+// FuzzInstruction runs one instruction from a random state on both Cpu and
+// refCPU and compares the results. The input holds A, F, B, C, D, E, H, L,
+// SP, PC and IME, the instruction bytes, then the seed the rest of memory is
+// filled from.
+func FuzzInstruction(f *testing.F) {
+	for op := 0; op < 0x100; op++ {
+		f.Add([]byte{0x01, 0xB0, 0x00, 0x13, 0x00, 0xD8, 0x01, 0x4D, 0xFE, 0xFF, 0x00, 0x01, 0x00, uint8(op), 0x7F, 0x80})
+		f.Add([]byte{0xFF, 0x50, 0x80, 0x0F, 0xF1, 0x10, 0xC0, 0x01, 0x00, 0xD0, 0xF0, 0x80, 0x01, uint8(op), 0xFE, 0x12, 0x34})
+		f.Add([]byte{0x9A, 0x00, 0x7F, 0x01, 0x00, 0x00, 0xFF, 0xFE, 0x01, 0x00, 0x34, 0x12, 0x00, 0xCB, uint8(op), 0x00, 0x99})
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		in := make([]byte, 20)
+		copy(in, data)
+
+		ref := &refCPU{
+			a: in[0], f: in[1] & 0xF0, b: in[2], c: in[3], d: in[4], e: in[5], h: in[6], l: in[7],
+			sp:  uint16(in[9])<<8 | uint16(in[8]),
+			pc:  uint16(in[11])<<8 | uint16(in[10]),
+			ime: in[12]&1 != 0,
+		}
+
+		seed := uint32(in[16]) | uint32(in[17])<<8 | uint32(in[18])<<16 | uint32(in[19])<<24 | 1
+		for i := range ref.mem {
+			seed ^= seed << 13
+			seed ^= seed >> 17
+			seed ^= seed << 5
+			ref.mem[i] = uint8(seed)
+		}
+
+		for i, b := range in[13:16] {
+			ref.mem[ref.pc+uint16(i)] = b
+		}
+
+		// an interrupt would be dispatched instead of running the instruction
+		if ref.ime {
+			ref.mem[InterruptFlag] = 0
+		}
+
+		mem := memory.NewMemory[uint16, uint8](0x10000)
+		for i, b := range ref.mem {
+			mem.Write(uint16(i), b)
+		}
+
+		c := New(mem)
+		if err := c.SetState(State{
+			A: ref.a, F: ref.f, B: ref.b, C: ref.c, D: ref.d, E: ref.e, H: ref.h, L: ref.l,
+			SP: ref.sp, PC: ref.pc, IME: ref.ime,
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		cycles := uint(0)
+		c.AfterInstruction(func(e Execution) {
+			cycles = e.Cycles
+		})
+
+		for i := 0; i < 8 && cycles == 0; i++ {
+			tick(c, 1)
+		}
+
+		name := Opcode(in[13]).Name
+		if in[13] == 0xCB {
+			name = ExtendedOpcode(in[14]).Name
+		}
+
+		wantCycles := uint(ref.step())
+		got := c.State()
+		want := State{
+			A: ref.a, F: ref.f, B: ref.b, C: ref.c, D: ref.d, E: ref.e, H: ref.h, L: ref.l,
+			SP: ref.sp, PC: ref.pc,
+			FlagZ: ref.flag(refZ), FlagN: ref.flag(refN), FlagH: ref.flag(refH), FlagC: ref.flag(refC),
+			IME: ref.ime, IMEPending: ref.imePending, Halted: ref.halted, Stopped: ref.stopped,
+		}
+
+		if got != want {
+			t.Errorf("%s: got\n%+v\nwant\n%+v", name, got, want)
+		}
+
+		if cycles != wantCycles {
+			t.Errorf("%s: took %d cycles, want %d", name, cycles, wantCycles)
+		}
+
+		if (c.Err() != nil) != ref.locked {
+			t.Errorf("%s: locked = %v, want %v", name, c.Err(), ref.locked)
+		}
+
+		for i, b := range ref.mem {
+			if v := mem.Read(uint16(i)); v != b {
+				t.Errorf("%s: [%04X] = %02X, want %02X", name, i, v, b)
+			}
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package cpu
+
+// JoypadRegister is P1, a STOP lasts until one of its input lines, the low
+// nibble, reads 0.
+const JoypadRegister uint16 = 0xFF00
+
+// halt suspends execution until an interrupt is pending. When IME is off and
+// an interrupt is already pending the CPU does not halt at all, instead the
+// next opcode fetch fails to increment PC and the following byte is read twice.
+func (c *Cpu) halt() {
+	if !c.ime && c.pendingInterrupts() != 0 {
+		c.haltBug = true
+		return
+	}
+
+	c.halted = true
+}
+
+func (c *Cpu) stop() {
+	if c.switchSpeed() {
+		return
+	}
+
+	c.stopped = true
+}
+
+func (c *Cpu) joypadLineLow() bool {
+	return c.mem.Read(JoypadRegister)&0x0F != 0x0F
+}
+
+func (c Cpu) Halted() bool {
+	return c.halted
+}
+
+// Stopped reports whether the system clock is stopped by STOP, the other
+// units should not be ticked until a joypad line goes low.
+func (c Cpu) Stopped() bool {
+	return c.stopped
+}
@@ -0,0 +1,68 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/mrratatosk/oort-gb/joypad"
+)
+
+func TestHalt(t *testing.T) {
+	// HALT / INC A
+	for _, ime := range []bool{true, false} {
+		c, mem := newTestCpu(0x100, 0x76, 0x3C)
+		c.ime = ime
+		mem.Write(InterruptEnable, 0x01)
+
+		tick(c, 10)
+		if !c.Halted() || c.Registers.PC != 0x101 {
+			t.Fatalf("IME %v: halted %v at 0x%04X", ime, c.Halted(), c.Registers.PC)
+		}
+
+		mem.Write(InterruptFlag, 0x01)
+		if ime {
+			// a wake-up cycle, then the dispatch
+			tick(c, 1+5)
+			if c.Registers.PC != VBlank.Vector() || mem.Read(0xCFFE) != 0x01 {
+				t.Errorf("IME set: PC = 0x%04X, returning to 0x%02X", c.Registers.PC, mem.Read(0xCFFE))
+			}
+			continue
+		}
+
+		// without IME, execution goes on after HALT and IF is left alone
+		tick(c, 1+1)
+		if c.Halted() || c.Registers.A != 1 || mem.Read(InterruptFlag) != 0x01 {
+			t.Errorf("IME clear: halted %v, A = %d, IF = 0x%02X", c.Halted(), c.Registers.A, mem.Read(InterruptFlag))
+		}
+	}
+}
+
+func TestHaltBug(t *testing.T) {
+	// HALT / INC A / NOP, with an interrupt pending and IME clear
+	c, mem := newTestCpu(0x100, 0x76, 0x3C, 0x00)
+	mem.Write(InterruptEnable, 0x01)
+	mem.Write(InterruptFlag, 0x01)
+
+	// the byte following HALT is read twice: INC A runs twice
+	tick(c, 3)
+	if c.Halted() || c.Registers.A != 2 || c.Registers.PC != 0x102 {
+		t.Errorf("halted %v, A = %d, PC = 0x%04X", c.Halted(), c.Registers.A, c.Registers.PC)
+	}
+}
+
+func TestStop(t *testing.T) {
+	// STOP / INC A
+	c, mem := newTestCpu(0x100, 0x10, 0x00, 0x3C)
+	pad := joypad.New(nil)
+	onMMU(c, mem).MapIO(JoypadRegister, JoypadRegister, pad)
+
+	tick(c, 100)
+	if !c.Stopped() || c.Registers.A != 0 {
+		t.Fatalf("stopped %v, A = %d with no button pressed", c.Stopped(), c.Registers.A)
+	}
+
+	pad.Press(joypad.Start)
+	tick(c, 1+1)
+	if c.Stopped() || c.Registers.A != 1 {
+		t.Errorf("stopped %v, A = %d after pressing Start", c.Stopped(), c.Registers.A)
+	}
+}
@@ -0,0 +1,71 @@
+package cpu
+
+import "fmt"
+
+// defaultHistory is the number of instructions recorded until SetHistorySize
+// is called.
+const defaultHistory = 32
+
+// HistoryEntry is an executed instruction, with the registers it left.
+type HistoryEntry struct {
+	PC        uint16
+	Opcode    [3]uint8
+	Length    uint8
+	Registers Registers
+}
+
+// Bytes returns the opcode and immediate bytes of the instruction.
+func (h HistoryEntry) Bytes() []uint8 {
+	return h.Opcode[:h.Length]
+}
+
+func (h HistoryEntry) String() string {
+	r := h.Registers
+
+	return fmt.Sprintf("%04X  %-8s  A:%02X F:%02X B:%02X C:%02X D:%02X E:%02X H:%02X L:%02X SP:%04X",
+		h.PC, fmt.Sprintf("% X", h.Bytes()), r.A, r.F, r.B, r.C, r.D, r.E, r.H, r.L, r.SP)
+}
+
+// SetHistorySize keeps the last n executed instructions, 0 or less stops
+// recording. The instructions recorded so far are dropped.
+func (c *Cpu) SetHistorySize(n int) {
+	if n < 0 {
+		n = 0
+	}
+
+	c.recent = make([]HistoryEntry, n)
+	c.recorded = 0
+}
+
+// History returns the last instructions executed, oldest first.
+func (c Cpu) History() []HistoryEntry {
+	n := c.recorded
+	if n > uint(len(c.recent)) {
+		n = uint(len(c.recent))
+	}
+
+	entries := make([]HistoryEntry, n)
+	for i := range entries {
+		entries[i] = c.recent[(c.recorded-n+uint(i))%uint(len(c.recent))]
+	}
+
+	return entries
+}
+
+// record adds the instruction that just ended to the history.
+func (c *Cpu) record() {
+	h := &c.recent[c.recorded%uint(len(c.recent))]
+	c.recorded++
+
+	h.PC, h.Length, h.Registers = c.origin, uint8(c.ins.Length), c.Registers
+	copy(h.Opcode[:], c.fetched[:c.fetchedLen])
+}
+
+// collect keeps the bytes fetched for the instruction in flight, for the
+// history to record them without reading memory again.
+func (c *Cpu) collect(value uint8) {
+	if c.fetchedLen < len(c.fetched) {
+		c.fetched[c.fetchedLen] = value
+		c.fetchedLen++
+	}
+}
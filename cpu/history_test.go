@@ -0,0 +1,38 @@
+package cpu
+
+import "testing"
+
+func TestHistory(t *testing.T) {
+	// LD A,$42 / LD BC,$1234 / INC A / CB 37 (SWAP A)
+	c, _ := newTestCpu(0x100, 0x3E, 0x42, 0x01, 0x34, 0x12, 0x3C, 0xCB, 0x37)
+	c.SetHistorySize(3)
+	tick(c, 2+3+1+2)
+
+	want := []string{
+		"0102  01 34 12  A:42 F:00 B:12 C:34 D:00 E:00 H:00 L:00 SP:D000",
+		"0105  3C        A:43 F:00 B:12 C:34 D:00 E:00 H:00 L:00 SP:D000",
+		"0106  CB 37     A:34 F:00 B:12 C:34 D:00 E:00 H:00 L:00 SP:D000",
+	}
+
+	history := c.History()
+	if len(history) != len(want) {
+		t.Fatalf("history = %v", history)
+	}
+	for i, h := range history {
+		if h.String() != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, h, want[i])
+		}
+	}
+
+	c.SetHistorySize(0)
+	tick(c, 1)
+	if len(c.History()) != 0 {
+		t.Errorf("history = %v after disabling it", c.History())
+	}
+
+	c.SetHistorySize(-1)
+	tick(c, 1)
+	if len(c.History()) != 0 {
+		t.Errorf("history = %v with a negative size", c.History())
+	}
+}
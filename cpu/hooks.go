@@ -0,0 +1,63 @@
+package cpu
+
+// Execution describes an instruction to the hooks run around it. A CB
+// prefixed instruction is reported once, as its extended instruction.
+type Execution struct {
+	Instruction Instruction
+	PC          uint16
+	Params      []uint8
+	// Cycles counts the clock cycles the instruction took, including a taken
+	// branch. Hooks run before the instruction always see 0.
+	Cycles uint
+}
+
+// Hook is called around every instruction the CPU executes.
+type Hook func(e Execution)
+
+// BeforeInstruction registers h to run at each instruction boundary, right
+// before the opcode fetch.
+func (c *Cpu) BeforeInstruction(h Hook) {
+	c.before = append(c.before, h)
+}
+
+// AfterInstruction registers h to run once the last cycle of each instruction
+// has been executed.
+func (c *Cpu) AfterInstruction(h Hook) {
+	c.after = append(c.after, h)
+}
+
+func (c *Cpu) hooked() bool {
+	return len(c.before) != 0 || len(c.after) != 0
+}
+
+// begin decodes the instruction about to be fetched, without side effects,
+// and runs the before hooks.
+func (c *Cpu) begin() {
+	pc := c.Registers.PC
+	code, length := c.mem.Read(pc), uint16(1)
+
+	ins := c.instructions[code]
+	if set, ok := c.extensions[code]; ok {
+		ins, length = set[c.mem.Read(pc+1)], 2
+	}
+
+	params := make([]uint8, ins.Params)
+	for i := range params {
+		params[i] = c.mem.Read(pc + length + uint16(i))
+	}
+
+	c.current = &Execution{Instruction: ins, PC: pc, Params: params}
+	for _, h := range c.before {
+		h(*c.current)
+	}
+}
+
+// end runs the after hooks once the instruction in flight is over.
+func (c *Cpu) end() {
+	e := *c.current
+	c.current = nil
+
+	for _, h := range c.after {
+		h(e)
+	}
+}
@@ -0,0 +1,43 @@
+package cpu
+
+import "testing"
+
+func TestHooks(t *testing.T) {
+	// JR NZ,+2 (taken) / NOP / NOP / BIT 7,(HL) / LD HL,$C000
+	c, _ := newTestCpu(0x100, 0x20, 0x02, 0x00, 0x00, 0xCB, 0x7E, 0x21, 0x00, 0xC0)
+
+	var before, after []Execution
+	c.BeforeInstruction(func(e Execution) { before = append(before, e) })
+	c.AfterInstruction(func(e Execution) { after = append(after, e) })
+
+	tick(c, 3+3+3)
+
+	want := []struct {
+		name   string
+		pc     uint16
+		params int
+		cycles uint
+	}{
+		{"JR NZ,e8", 0x100, 1, 12},
+		{"BIT 7,(HL)", 0x104, 0, 12},
+		{"LD HL,n16", 0x106, 2, 12},
+	}
+
+	if len(before) != len(want) || len(after) != len(want) {
+		t.Fatalf("got %d before and %d after hooks, want %d", len(before), len(after), len(want))
+	}
+
+	for i, w := range want {
+		b, a := before[i], after[i]
+		if b.Instruction.Name != w.name || b.PC != w.pc || len(b.Params) != w.params || b.Cycles != 0 {
+			t.Errorf("before %d = %s at %04X %v %d", i, b.Instruction.Name, b.PC, b.Params, b.Cycles)
+		}
+		if a.Instruction.Name != w.name || a.PC != w.pc || a.Cycles != w.cycles {
+			t.Errorf("after %d = %s at %04X took %d, want %d", i, a.Instruction.Name, a.PC, a.Cycles, w.cycles)
+		}
+	}
+
+	if p := after[2].Params; p[0] != 0x00 || p[1] != 0xC0 {
+		t.Errorf("LD HL,n16 params = %v", p)
+	}
+}
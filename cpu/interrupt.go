@@ -0,0 +1,57 @@
+package cpu
+
+import (
+	"math/bits"
+
+	"github.com/mrratatosk/oort-framework/tools"
+)
+
+type Interrupt uint8
+
+const (
+	VBlank Interrupt = iota
+	LcdStat
+	Timer
+	Serial
+	Joypad
+)
+
+const (
+	InterruptFlag   uint16 = 0xFF0F
+	InterruptEnable uint16 = 0xFFFF
+)
+
+const interruptCycles = 20
+
+func (i Interrupt) Vector() uint16 {
+	return 0x40 + uint16(i)*8
+}
+
+// RequestInterrupt raises the IF bit of the given source, it is serviced on
+// the next instruction boundary if both IME and the matching IE bit are set.
+func (c *Cpu) RequestInterrupt(i Interrupt) {
+	c.mem.Write(InterruptFlag, tools.Set8(c.mem.Read(InterruptFlag), uint(i)))
+}
+
+func (c *Cpu) pendingInterrupts() uint8 {
+	return c.mem.Read(InterruptEnable) & c.mem.Read(InterruptFlag) & 0x1F
+}
+
+// interruptDispatch pushes PC and jumps to the vector of the highest priority
+// pending interrupt. The source is only picked once the high byte of PC has
+// been pushed: if that push overwrote IE and nothing is pending anymore, the
+// dispatch is cancelled and execution resumes at 0x0000.
+var interruptDispatch = newIns("INT", interruptCycles, 0, nil, nil, pushPCHigh, func(c *Cpu) {
+	pending := c.pendingInterrupts()
+	pushPCLow(c)
+
+	c.z, c.w = 0x00, 0x00
+	if pending != 0 {
+		i := Interrupt(bits.TrailingZeros8(pending))
+		c.mem.Write(InterruptFlag, tools.Clear8(c.mem.Read(InterruptFlag), uint(i)))
+		c.w, c.z = tools.Split8(i.Vector())
+	}
+}, func(c *Cpu) {
+	jumpAbsolute(c)
+	c.enter(true)
+})
@@ -0,0 +1,91 @@
+package cpu
+
+import "testing"
+
+func TestInterruptDispatch(t *testing.T) {
+	c, mem := newTestCpu(0x1234, 0x00)
+	c.ime = true
+	mem.Write(InterruptEnable, 0x1F)
+	mem.Write(InterruptFlag, 0x14)
+
+	// the dispatch lasts 5 M-cycles
+	tick(c, 4)
+	if c.Registers.PC != 0x1234 {
+		t.Fatalf("PC = 0x%04X after 4 cycles of dispatch", c.Registers.PC)
+	}
+
+	tick(c, 1)
+	if c.Registers.PC != Timer.Vector() {
+		t.Errorf("PC = 0x%04X, want the timer vector 0x%04X", c.Registers.PC, Timer.Vector())
+	}
+	if c.Registers.SP != 0xCFFE || mem.Read(0xCFFF) != 0x12 || mem.Read(0xCFFE) != 0x34 {
+		t.Errorf("pushed %02X%02X at SP 0x%04X", mem.Read(0xCFFF), mem.Read(0xCFFE), c.Registers.SP)
+	}
+	if f := mem.Read(InterruptFlag); f != 0x10 {
+		t.Errorf("IF = 0x%02X, only the timer bit should be cleared", f)
+	}
+	if c.ime {
+		t.Error("IME still set in the handler")
+	}
+}
+
+func TestEIDelay(t *testing.T) {
+	// EI / NOP / NOP
+	c, mem := newTestCpu(0x100, 0xFB, 0x00, 0x00)
+	mem.Write(InterruptEnable, 0x01)
+	mem.Write(InterruptFlag, 0x01)
+
+	tick(c, 2)
+	if c.Registers.PC != 0x102 {
+		t.Fatalf("PC = 0x%04X, the instruction after EI must run first", c.Registers.PC)
+	}
+
+	tick(c, 5)
+	if c.Registers.PC != VBlank.Vector() || mem.Read(0xCFFE) != 0x02 {
+		t.Errorf("PC = 0x%04X, return address low byte 0x%02X", c.Registers.PC, mem.Read(0xCFFE))
+	}
+}
+
+func TestRETI(t *testing.T) {
+	// RETI, returning to a NOP at $0200
+	c, mem := newTestCpu(0x100, 0xD9)
+	mem.WriteRange(0xCFFE, []uint8{0x00, 0x02})
+	c.Registers.SP = 0xCFFE
+	mem.Write(InterruptEnable, 0x01)
+	mem.Write(InterruptFlag, 0x01)
+
+	// IME is set right away, the dispatch comes before the NOP
+	tick(c, 4+5)
+	if c.Registers.PC != VBlank.Vector() || mem.Read(0xCFFF) != 0x02 || mem.Read(0xCFFE) != 0x00 {
+		t.Errorf("PC = 0x%04X, returning to %02X%02X", c.Registers.PC, mem.Read(0xCFFF), mem.Read(0xCFFE))
+	}
+}
+
+// Pushing PC with SP at 0x0000 writes its high byte to IE, the interrupt is
+// only picked after that.
+func TestDispatchOverwritingIE(t *testing.T) {
+	for _, test := range []struct {
+		pc   uint16
+		want uint16
+		flag uint8
+	}{
+		// IE becomes 0x02, VBlank is no longer enabled: cancelled
+		{0x0200, 0x0000, 0x05},
+		// IE becomes 0x04, the timer is serviced instead of VBlank
+		{0x0400, Timer.Vector(), 0x01},
+	} {
+		c, mem := newTestCpu(test.pc, 0x00)
+		c.Registers.SP = 0x0000
+		c.ime = true
+		mem.Write(InterruptEnable, 0x01)
+		mem.Write(InterruptFlag, 0x05)
+
+		tick(c, 5)
+		if c.Registers.PC != test.want {
+			t.Errorf("PC 0x%04X: jumped to 0x%04X, want 0x%04X", test.pc, c.Registers.PC, test.want)
+		}
+		if f := mem.Read(InterruptFlag); f != test.flag {
+			t.Errorf("PC 0x%04X: IF = 0x%02X, want 0x%02X", test.pc, f, test.flag)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package cpu
+
+import "fmt"
+
+// LockupError describes the illegal opcode that hung the CPU. Like the
+// hardware, a locked CPU no longer executes anything until it is reset.
+type LockupError struct {
+	Opcode uint8
+	PC     uint16
+}
+
+func (e *LockupError) Error() string {
+	return fmt.Sprintf("cpu locked up by illegal opcode 0x%02X at 0x%04X", e.Opcode, e.PC)
+}
+
+func (c *Cpu) lockup(opcode uint8) {
+	c.crash(&LockupError{
+		Opcode: opcode,
+		PC:     c.origin,
+	})
+}
@@ -0,0 +1,233 @@
+package cpu
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// Metadata describes an opcode for tools such as analysers and debuggers.
+type Metadata struct {
+	Opcode       uint8       `json:"opcode"`
+	Prefixed     bool        `json:"prefixed"`
+	Mnemonic     string      `json:"mnemonic"`
+	Name         string      `json:"name"`
+	Length       uint        `json:"length"`
+	Cycles       uint        `json:"cycles"`
+	BranchCycles uint        `json:"branchCycles"`
+	Operands     []Operand   `json:"operands"`
+	Flags        FlagEffects `json:"flags"`
+	Memory       Access      `json:"memory"`
+	Flow         Flow        `json:"flow,omitempty"`
+	Conditional  bool        `json:"conditional"`
+}
+
+type OperandKind string
+
+const (
+	R8       OperandKind = "r8"
+	R16      OperandKind = "r16"
+	Imm8     OperandKind = "imm8"
+	Imm16    OperandKind = "imm16"
+	Rel8     OperandKind = "rel8"
+	SImm8    OperandKind = "simm8"
+	SPOffset OperandKind = "sp+simm8"
+	// IndirectHL covers (HL), (HL+) and (HL-).
+	IndirectHL OperandKind = "(HL)"
+	Indirect16 OperandKind = "(r16)"
+	Absolute   OperandKind = "(imm16)"
+	// IOOffset is an offset from 0xFF00, in C or an immediate byte.
+	IOOffset  OperandKind = "io-offset"
+	Condition OperandKind = "cond"
+	BitIndex  OperandKind = "bit"
+	Vector    OperandKind = "vector"
+)
+
+// Operand is an operand of the mnemonic, as written in Name.
+type Operand struct {
+	Kind OperandKind `json:"kind"`
+	Name string      `json:"name"`
+}
+
+type FlagEffect string
+
+const (
+	Unchanged FlagEffect = "unchanged"
+	Reset     FlagEffect = "reset"
+	Set       FlagEffect = "set"
+	Computed  FlagEffect = "computed"
+)
+
+type FlagEffects struct {
+	Z FlagEffect `json:"z"`
+	N FlagEffect `json:"n"`
+	H FlagEffect `json:"h"`
+	C FlagEffect `json:"c"`
+}
+
+// Access counts the bytes read and written in memory besides the
+// instruction itself, for a conditional instruction when its branch is taken.
+type Access struct {
+	Reads  uint `json:"reads"`
+	Writes uint `json:"writes"`
+	Stack  bool `json:"stack"`
+}
+
+type Flow string
+
+const (
+	Jump   Flow = "jump"
+	Call   Flow = "call"
+	Return Flow = "return"
+	Halt   Flow = "halt"
+	Lockup Flow = "lockup"
+)
+
+// Table returns the metadata of every opcode, the unprefixed ones first then
+// those following a 0xCB prefix.
+func Table() []Metadata {
+	table := make([]Metadata, 0, 512)
+	for _, set := range []*[256]Instruction{instructionSet, extensionSet} {
+		for _, ins := range set {
+			table = append(table, *ins.Meta)
+		}
+	}
+
+	return table
+}
+
+// ExportMetadata writes the Table to w as JSON.
+func ExportMetadata(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(Table())
+}
+
+func describe(code uint8, op opcode, prefixed bool) *Metadata {
+	name, args := parseMnemonic(op.mnemonic)
+
+	m := &Metadata{
+		Opcode:       code,
+		Prefixed:     prefixed,
+		Mnemonic:     name,
+		Name:         op.mnemonic,
+		Length:       op.length,
+		Cycles:       op.cycles,
+		BranchCycles: op.cycles,
+		Operands:     []Operand{},
+		Flow:         flows[name],
+	}
+
+	if op.branch != 0 {
+		m.BranchCycles, m.Conditional = op.branch, true
+	}
+
+	effects := [4]FlagEffect{}
+	for i, f := range op.flags {
+		switch f {
+		case '-':
+			effects[i] = Unchanged
+		case '0':
+			effects[i] = Reset
+		case '1':
+			effects[i] = Set
+		default:
+			effects[i] = Computed
+		}
+	}
+	m.Flags = FlagEffects{effects[0], effects[1], effects[2], effects[3]}
+
+	// the byte following PREFIX is the opcode of the extended instruction
+	if name == "PREFIX" {
+		args = nil
+	}
+
+	for i, arg := range args {
+		kind := operandKind(name, i, arg)
+		m.Operands = append(m.Operands, Operand{kind, arg})
+
+		if isMemory(kind) {
+			size := uint(1)
+			if len(args) > 1 && args[1] == "SP" {
+				size = 2
+			}
+
+			switch {
+			case name == "BIT":
+				m.Memory.Reads = size
+			case i == 0 && (name == "LD" || name == "LDH"):
+				m.Memory.Writes = size
+			case name == "LD" || name == "LDH" || aluOps[name] != nil:
+				m.Memory.Reads = size
+			default:
+				m.Memory.Reads, m.Memory.Writes = size, size
+			}
+		}
+	}
+
+	switch name {
+	case "PUSH", "CALL", "RST":
+		m.Memory = Access{Writes: 2, Stack: true}
+	case "POP", "RET", "RETI":
+		m.Memory = Access{Reads: 2, Stack: true}
+	}
+
+	return m
+}
+
+var flows = map[string]Flow{
+	"JP":      Jump,
+	"JR":      Jump,
+	"CALL":    Call,
+	"RST":     Call,
+	"RET":     Return,
+	"RETI":    Return,
+	"HALT":    Halt,
+	"STOP":    Halt,
+	"ILLEGAL": Lockup,
+}
+
+func operandKind(name string, i int, arg string) OperandKind {
+	switch {
+	case i == 0 && flows[name] != "" && conditions[arg] != nil:
+		return Condition
+	case i == 0 && (name == "BIT" || name == "RES" || name == "SET"):
+		return BitIndex
+	case name == "RST":
+		return Vector
+	case registers8[arg] != nil:
+		return R8
+	case pairs[arg].get != nil:
+		return R16
+	case arg == "e8" && name == "JR":
+		return Rel8
+	}
+
+	switch arg {
+	case "n8":
+		return Imm8
+	case "n16", "a16":
+		return Imm16
+	case "e8":
+		return SImm8
+	case "SP+e8":
+		return SPOffset
+	case "(a16)":
+		return Absolute
+	case "(a8)", "(C)":
+		return IOOffset
+	case "(BC)", "(DE)":
+		return Indirect16
+	}
+
+	if strings.HasPrefix(arg, "(HL") {
+		return IndirectHL
+	}
+
+	return ""
+}
+
+func isMemory(kind OperandKind) bool {
+	return kind == IndirectHL || kind == Indirect16 || kind == Absolute || kind == IOOffset
+}
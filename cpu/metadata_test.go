@@ -0,0 +1,55 @@
+package cpu
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMetadata(t *testing.T) {
+	var out bytes.Buffer
+	if err := ExportMetadata(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	var table []Metadata
+	if err := json.Unmarshal(out.Bytes(), &table); err != nil || len(table) != 512 {
+		t.Fatalf("got %d entries: %v", len(table), err)
+	}
+
+	for _, m := range table {
+		for _, op := range m.Operands {
+			if op.Kind == "" {
+				t.Errorf("%s: operand %s has no kind", m.Name, op.Name)
+			}
+		}
+	}
+
+	want := map[int]Metadata{
+		0x20: {
+			Opcode: 0x20, Mnemonic: "JR", Name: "JR NZ,e8", Length: 2, Cycles: 8, BranchCycles: 12,
+			Operands: []Operand{{Condition, "NZ"}, {Rel8, "e8"}},
+			Flags:    FlagEffects{Unchanged, Unchanged, Unchanged, Unchanged},
+			Flow:     Jump, Conditional: true,
+		},
+		0xE0: {
+			Opcode: 0xE0, Mnemonic: "LDH", Name: "LDH (a8),A", Length: 2, Cycles: 12, BranchCycles: 12,
+			Operands: []Operand{{IOOffset, "(a8)"}, {R8, "A"}},
+			Flags:    FlagEffects{Unchanged, Unchanged, Unchanged, Unchanged},
+			Memory:   Access{Writes: 1},
+		},
+		0x100 + 0x46: {
+			Opcode: 0x46, Prefixed: true, Mnemonic: "BIT", Name: "BIT 0,(HL)", Length: 2, Cycles: 12, BranchCycles: 12,
+			Operands: []Operand{{BitIndex, "0"}, {IndirectHL, "(HL)"}},
+			Flags:    FlagEffects{Computed, Reset, Set, Unchanged},
+			Memory:   Access{Reads: 1},
+		},
+	}
+
+	for i, m := range want {
+		if !reflect.DeepEqual(table[i], m) {
+			t.Errorf("entry %03X = %+v, want %+v", i, table[i], m)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package cpu
+
+// OAM is scanned by the PPU in mode 2, 20 rows of 8 bytes, two sprites each.
+const (
+	oamStart uint16 = 0xFE00
+	oamRows         = 20
+)
+
+// OAMScan reports the OAM row the PPU reads in the current cycle, ok is false
+// outside of mode 2.
+type OAMScan func() (row int, ok bool)
+
+// SetOAMScan lets the CPU know what the PPU is doing, which enables the OAM
+// corruption bug of the DMG: 16-bit increments and decrements, LDI, LDD, PUSH
+// and POP with an address in 0xFE00-0xFEFF during mode 2 garble the row the
+// PPU is reading. CGB models are not affected.
+func (c *Cpu) SetOAMScan(scan OAMScan) {
+	c.oamScan = scan
+}
+
+// oamRow returns the row corrupted by an access to address, if any.
+func (c *Cpu) oamRow(address uint16) (int, bool) {
+	if c.model != DMG || c.oamScan == nil || address>>8 != oamStart>>8 {
+		return 0, false
+	}
+
+	row, ok := c.oamScan()
+	// the first row is never affected
+	return row, ok && row > 0 && row < oamRows
+}
+
+func (c *Cpu) oamWord(row int, word int) uint16 {
+	address := oamStart + uint16(row*8+word*2)
+	return uint16(c.mem.Read(address+1))<<8 | uint16(c.mem.Read(address))
+}
+
+func (c *Cpu) setOAMWord(row int, word int, value uint16) {
+	address := oamStart + uint16(row*8+word*2)
+	c.mem.Write(address, uint8(value))
+	c.mem.Write(address+1, uint8(value>>8))
+}
+
+// copyOAMRow copies the last three words of a row, or all of them.
+func (c *Cpu) copyOAMRow(from int, to int, first int) {
+	for word := first; word < 4; word++ {
+		c.setOAMWord(to, word, c.oamWord(from, word))
+	}
+}
+
+// oamWrite is the corruption caused by a write or an increment alone.
+func (c *Cpu) oamWrite(address uint16) {
+	row, ok := c.oamRow(address)
+	if !ok {
+		return
+	}
+
+	a, b, cc := c.oamWord(row, 0), c.oamWord(row-1, 0), c.oamWord(row-1, 2)
+	c.setOAMWord(row, 0, ((a^cc)&(b^cc))^cc)
+	c.copyOAMRow(row-1, row, 1)
+}
+
+// oamRead is the corruption caused by a read.
+func (c *Cpu) oamRead(address uint16) {
+	row, ok := c.oamRow(address)
+	if !ok {
+		return
+	}
+
+	a, b, cc := c.oamWord(row, 0), c.oamWord(row-1, 0), c.oamWord(row-1, 2)
+	c.setOAMWord(row, 0, b|(a&cc))
+	c.copyOAMRow(row-1, row, 1)
+}
+
+// oamReadIncrement is the corruption caused by a read and an increment in the
+// same cycle. Past the first four rows, and but for the last one, the
+// preceding row is garbled and copied over its two neighbours first.
+func (c *Cpu) oamReadIncrement(address uint16) {
+	row, ok := c.oamRow(address)
+	if ok && row >= 4 && row < oamRows-1 {
+		a, b, cc, d := c.oamWord(row-2, 0), c.oamWord(row-1, 0), c.oamWord(row, 0), c.oamWord(row-1, 2)
+		c.setOAMWord(row-1, 0, (b&(a|cc|d))|(a&cc&d))
+		c.copyOAMRow(row-1, row, 0)
+		c.copyOAMRow(row-1, row-2, 0)
+	}
+
+	c.oamRead(address)
+}
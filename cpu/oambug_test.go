@@ -0,0 +1,67 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/mrratatosk/oort-framework/memory"
+)
+
+func TestOAMBug(t *testing.T) {
+	setup := func(model Model, code ...uint8) (*Cpu, *memory.Memory[uint16, uint8]) {
+		c, mem := newTestCpu(0x100, code...)
+		for i := uint16(0); i < 0xA0; i++ {
+			mem.Write(oamStart+i, uint8(i))
+		}
+
+		c.SetModel(model)
+		c.SetOAMScan(func() (int, bool) { return 5, true })
+		c.Registers.SetHL(0xFE10)
+		c.Registers.SP = 0xFE20
+
+		return c, mem
+	}
+
+	// INC HL: write corruption of row 5 from row 4
+	c, mem := setup(DMG, 0x23)
+	tick(c, 2)
+	a, b, cc := uint16(0x2928), uint16(0x2120), uint16(0x2524)
+	if got, want := c.oamWord(5, 0), ((a^cc)&(b^cc))^cc; got != want {
+		t.Errorf("INC HL: row 5 word 0 = 0x%04X, want 0x%04X", got, want)
+	}
+	for i := uint16(2); i < 8; i++ {
+		if got := mem.Read(oamStart + 5*8 + i); got != uint8(4*8+i) {
+			t.Errorf("INC HL: row 5 byte %d = 0x%02X, want 0x%02X", i, got, 4*8+i)
+		}
+	}
+
+	// LD A,(HL+): read during increment garbles row 4 and copies it to rows 3 and 5
+	c, _ = setup(DMG, 0x2A)
+	tick(c, 2)
+	a, b, cc, d := uint16(0x1918), uint16(0x2120), uint16(0x2928), uint16(0x2524)
+	row4 := (b & (a | cc | d)) | (a & cc & d)
+	if c.oamWord(4, 0) != row4 || c.oamWord(3, 0) != row4 || c.oamWord(3, 3) != c.oamWord(4, 3) {
+		t.Errorf("LD A,(HL+): rows 3 and 4 = 0x%04X 0x%04X, want 0x%04X", c.oamWord(3, 0), c.oamWord(4, 0), row4)
+	}
+	if got, want := c.oamWord(5, 0), row4|(row4&c.oamWord(4, 2)); got != want {
+		t.Errorf("LD A,(HL+): row 5 word 0 = 0x%04X, want 0x%04X", got, want)
+	}
+
+	// PUSH and POP outside of OAM, or on CGB, only write the pushed bytes
+	for _, test := range []struct {
+		model Model
+		sp    uint16
+	}{{DMG, 0xC000}, {CGB, 0xFE20}} {
+		c, mem = setup(test.model, 0xC5, 0xC1)
+		c.Registers.SP = test.sp
+		tick(c, 4+3)
+		for i := uint16(0); i < 0xA0; i++ {
+			if got := mem.Read(oamStart + i); got != uint8(i) && (oamStart+i < test.sp-2 || oamStart+i >= test.sp) {
+				t.Errorf("model %d, SP 0x%04X: OAM byte %d = 0x%02X", test.model, test.sp, i, got)
+				break
+			}
+		}
+		if c.Registers.SP != test.sp {
+			t.Errorf("SP = 0x%04X after PUSH and POP", c.Registers.SP)
+		}
+	}
+}
@@ -0,0 +1,507 @@
+package cpu
+
+// refCPU is a straightforward SM83 model used to cross-check Cpu. It decodes
+// opcodes from their bit fields rather than from the opcode tables, and runs a
+// whole instruction at once.
+type refCPU struct {
+	a, f, b, c, d, e, h, l uint8
+	sp, pc                 uint16
+
+	ime, imePending bool
+	halted, stopped bool
+	locked          bool
+
+	mem [0x10000]uint8
+}
+
+const (
+	refZ uint8 = 0x80
+	refN uint8 = 0x40
+	refH uint8 = 0x20
+	refC uint8 = 0x10
+)
+
+func (r *refCPU) flag(mask uint8) bool {
+	return r.f&mask != 0
+}
+
+func (r *refCPU) setFlags(z, n, h, c bool) {
+	r.f = 0
+	for mask, on := range map[uint8]bool{refZ: z, refN: n, refH: h, refC: c} {
+		if on {
+			r.f |= mask
+		}
+	}
+}
+
+func (r *refCPU) imm8() uint8 {
+	v := r.mem[r.pc]
+	r.pc++
+	return v
+}
+
+func (r *refCPU) imm16() uint16 {
+	lo := r.imm8()
+	return uint16(r.imm8())<<8 | uint16(lo)
+}
+
+func (r *refCPU) push(v uint16) {
+	r.sp--
+	r.mem[r.sp] = uint8(v >> 8)
+	r.sp--
+	r.mem[r.sp] = uint8(v)
+}
+
+func (r *refCPU) pop() uint16 {
+	lo := r.mem[r.sp]
+	r.sp++
+	hi := r.mem[r.sp]
+	r.sp++
+	return uint16(hi)<<8 | uint16(lo)
+}
+
+func (r *refCPU) hl() uint16 {
+	return uint16(r.h)<<8 | uint16(r.l)
+}
+
+func (r *refCPU) setHL(v uint16) {
+	r.h, r.l = uint8(v>>8), uint8(v)
+}
+
+// reg reads B, C, D, E, H, L, (HL) or A by their encoding.
+func (r *refCPU) reg(i uint8) uint8 {
+	switch i {
+	case 0:
+		return r.b
+	case 1:
+		return r.c
+	case 2:
+		return r.d
+	case 3:
+		return r.e
+	case 4:
+		return r.h
+	case 5:
+		return r.l
+	case 6:
+		return r.mem[r.hl()]
+	}
+	return r.a
+}
+
+func (r *refCPU) setReg(i uint8, v uint8) {
+	switch i {
+	case 0:
+		r.b = v
+	case 1:
+		r.c = v
+	case 2:
+		r.d = v
+	case 3:
+		r.e = v
+	case 4:
+		r.h = v
+	case 5:
+		r.l = v
+	case 6:
+		r.mem[r.hl()] = v
+	default:
+		r.a = v
+	}
+}
+
+// pair reads BC, DE, HL and SP, or AF in place of SP for PUSH and POP.
+func (r *refCPU) pair(i uint8, af bool) uint16 {
+	switch i {
+	case 0:
+		return uint16(r.b)<<8 | uint16(r.c)
+	case 1:
+		return uint16(r.d)<<8 | uint16(r.e)
+	case 2:
+		return r.hl()
+	}
+	if af {
+		return uint16(r.a)<<8 | uint16(r.f)
+	}
+	return r.sp
+}
+
+func (r *refCPU) setPair(i uint8, v uint16, af bool) {
+	hi, lo := uint8(v>>8), uint8(v)
+	switch {
+	case i == 0:
+		r.b, r.c = hi, lo
+	case i == 1:
+		r.d, r.e = hi, lo
+	case i == 2:
+		r.h, r.l = hi, lo
+	case af:
+		r.a, r.f = hi, lo&0xF0
+	default:
+		r.sp = v
+	}
+}
+
+func (r *refCPU) cond(i uint8) bool {
+	switch i {
+	case 0:
+		return !r.flag(refZ)
+	case 1:
+		return r.flag(refZ)
+	case 2:
+		return !r.flag(refC)
+	}
+	return r.flag(refC)
+}
+
+func (r *refCPU) alu(op uint8, v uint8) {
+	a, carry := int(r.a), 0
+	if r.flag(refC) {
+		carry = 1
+	}
+
+	switch op {
+	case 0, 1:
+		if op == 0 {
+			carry = 0
+		}
+		sum := a + int(v) + carry
+		r.setFlags(uint8(sum) == 0, false, a&0xF+int(v)&0xF+carry > 0xF, sum > 0xFF)
+		r.a = uint8(sum)
+	case 2, 3, 7:
+		if op != 3 {
+			carry = 0
+		}
+		diff := a - int(v) - carry
+		r.setFlags(uint8(diff) == 0, true, a&0xF-int(v)&0xF-carry < 0, diff < 0)
+		if op != 7 {
+			r.a = uint8(diff)
+		}
+	case 4:
+		r.a &= v
+		r.setFlags(r.a == 0, false, true, false)
+	case 5:
+		r.a ^= v
+		r.setFlags(r.a == 0, false, false, false)
+	case 6:
+		r.a |= v
+		r.setFlags(r.a == 0, false, false, false)
+	}
+}
+
+// rot runs the CB rotations and shifts, RLCA, RRCA, RLA and RRA use it with Z
+// cleared afterwards.
+func (r *refCPU) rot(op uint8, v uint8) uint8 {
+	carryIn := uint8(0)
+	if r.flag(refC) {
+		carryIn = 1
+	}
+
+	var res uint8
+	var carry bool
+	switch op {
+	case 0:
+		res, carry = v<<1|v>>7, v&0x80 != 0
+	case 1:
+		res, carry = v>>1|v<<7, v&1 != 0
+	case 2:
+		res, carry = v<<1|carryIn, v&0x80 != 0
+	case 3:
+		res, carry = v>>1|carryIn<<7, v&1 != 0
+	case 4:
+		res, carry = v<<1, v&0x80 != 0
+	case 5:
+		res, carry = v>>1|v&0x80, v&1 != 0
+	case 6:
+		res = v<<4 | v>>4
+	case 7:
+		res, carry = v>>1, v&1 != 0
+	}
+
+	r.setFlags(res == 0, false, false, carry)
+	return res
+}
+
+func (r *refCPU) addSP(e uint8) uint16 {
+	sp := r.sp
+	r.setFlags(false, false, sp&0xF+uint16(e)&0xF > 0xF, sp&0xFF+uint16(e) > 0xFF)
+	return sp + uint16(int8(e))
+}
+
+// step runs one instruction and returns the clock cycles it took.
+func (r *refCPU) step() int {
+	if r.locked {
+		return 0
+	}
+
+	op := r.imm8()
+	x, y, z := op>>6, op>>3&7, op&7
+	p, q := y>>1, y&1
+
+	switch x {
+	case 0:
+		switch z {
+		case 0:
+			switch {
+			case y == 0:
+				return 4
+			case y == 1:
+				a := r.imm16()
+				r.mem[a], r.mem[a+1] = uint8(r.sp), uint8(r.sp>>8)
+				return 20
+			case y == 2:
+				r.pc++
+				r.stopped = true
+				return 4
+			case y == 3 || r.cond(y-4):
+				e := r.imm8()
+				r.pc += uint16(int8(e))
+				return 12
+			}
+			r.pc++
+			return 8
+		case 1:
+			if q == 0 {
+				r.setPair(p, r.imm16(), false)
+				return 12
+			}
+			hl, v := r.hl(), r.pair(p, false)
+			r.setFlags(r.flag(refZ), false, hl&0xFFF+v&0xFFF > 0xFFF, uint32(hl)+uint32(v) > 0xFFFF)
+			r.setHL(hl + v)
+			return 8
+		case 2:
+			var addr uint16
+			switch p {
+			case 0:
+				addr = r.pair(0, false)
+			case 1:
+				addr = r.pair(1, false)
+			case 2:
+				addr = r.hl()
+				r.setHL(addr + 1)
+			case 3:
+				addr = r.hl()
+				r.setHL(addr - 1)
+			}
+			if q == 0 {
+				r.mem[addr] = r.a
+			} else {
+				r.a = r.mem[addr]
+			}
+			return 8
+		case 3:
+			if q == 0 {
+				r.setPair(p, r.pair(p, false)+1, false)
+			} else {
+				r.setPair(p, r.pair(p, false)-1, false)
+			}
+			return 8
+		case 4, 5:
+			v := r.reg(y)
+			res := v + 1
+			half := v&0xF == 0xF
+			if z == 5 {
+				res, half = v-1, v&0xF == 0
+			}
+			r.setReg(y, res)
+			r.setFlags(res == 0, z == 5, half, r.flag(refC))
+			if y == 6 {
+				return 12
+			}
+			return 4
+		case 6:
+			r.setReg(y, r.imm8())
+			if y == 6 {
+				return 12
+			}
+			return 8
+		}
+
+		switch y {
+		case 0, 1, 2, 3:
+			r.a = r.rot(y, r.a)
+			r.f &^= refZ
+		case 4:
+			a, carry := r.a, r.flag(refC)
+			if r.flag(refN) {
+				if carry {
+					a -= 0x60
+				}
+				if r.flag(refH) {
+					a -= 0x06
+				}
+			} else {
+				if carry || a > 0x99 {
+					a += 0x60
+					carry = true
+				}
+				if r.flag(refH) || a&0xF > 9 {
+					a += 0x06
+				}
+			}
+			r.a = a
+			r.setFlags(a == 0, r.flag(refN), false, carry)
+		case 5:
+			r.a = ^r.a
+			r.f |= refN | refH
+		case 6:
+			r.setFlags(r.flag(refZ), false, false, true)
+		case 7:
+			r.setFlags(r.flag(refZ), false, false, !r.flag(refC))
+		}
+		return 4
+	case 1:
+		if y == 6 && z == 6 {
+			if !r.ime && r.mem[0xFFFF]&r.mem[0xFF0F]&0x1F != 0 {
+				// halt bug, observable only on the next fetch
+				return 4
+			}
+			r.halted = true
+			return 4
+		}
+		r.setReg(y, r.reg(z))
+		if y == 6 || z == 6 {
+			return 8
+		}
+		return 4
+	case 2:
+		r.alu(y, r.reg(z))
+		if z == 6 {
+			return 8
+		}
+		return 4
+	}
+
+	switch z {
+	case 0:
+		switch y {
+		case 4:
+			r.mem[0xFF00+uint16(r.imm8())] = r.a
+			return 12
+		case 5:
+			r.sp = r.addSP(r.imm8())
+			return 16
+		case 6:
+			r.a = r.mem[0xFF00+uint16(r.imm8())]
+			return 12
+		case 7:
+			r.setHL(r.addSP(r.imm8()))
+			return 12
+		}
+		if r.cond(y) {
+			r.pc = r.pop()
+			return 20
+		}
+		return 8
+	case 1:
+		if q == 0 {
+			r.setPair(p, r.pop(), true)
+			return 12
+		}
+		switch p {
+		case 0, 1:
+			r.pc = r.pop()
+			if p == 1 {
+				r.ime = true
+			}
+			return 16
+		case 2:
+			r.pc = r.hl()
+			return 4
+		}
+		r.sp = r.hl()
+		return 8
+	case 2:
+		switch y {
+		case 4:
+			r.mem[0xFF00+uint16(r.c)] = r.a
+			return 8
+		case 5:
+			r.mem[r.imm16()] = r.a
+			return 16
+		case 6:
+			r.a = r.mem[0xFF00+uint16(r.c)]
+			return 8
+		case 7:
+			r.a = r.mem[r.imm16()]
+			return 16
+		}
+		a := r.imm16()
+		if r.cond(y) {
+			r.pc = a
+			return 16
+		}
+		return 12
+	case 3:
+		switch y {
+		case 0:
+			r.pc = r.imm16()
+			return 16
+		case 1:
+			return r.stepCB()
+		case 6:
+			r.ime, r.imePending = false, false
+			return 4
+		case 7:
+			r.imePending = true
+			return 4
+		}
+	case 4:
+		if y < 4 {
+			a := r.imm16()
+			if r.cond(y) {
+				r.push(r.pc)
+				r.pc = a
+				return 24
+			}
+			return 12
+		}
+	case 5:
+		if q == 0 {
+			r.push(r.pair(p, true))
+			return 16
+		}
+		if p == 0 {
+			a := r.imm16()
+			r.push(r.pc)
+			r.pc = a
+			return 24
+		}
+	case 6:
+		r.alu(y, r.imm8())
+		return 8
+	case 7:
+		r.push(r.pc)
+		r.pc = uint16(y) * 8
+		return 16
+	}
+
+	r.locked = true
+	return 4
+}
+
+func (r *refCPU) stepCB() int {
+	op := r.imm8()
+	x, y, z := op>>6, op>>3&7, op&7
+
+	v := r.reg(z)
+	switch x {
+	case 0:
+		r.setReg(z, r.rot(y, v))
+	case 1:
+		r.setFlags(v&(1<<y) == 0, false, true, r.flag(refC))
+		if z == 6 {
+			return 12
+		}
+		return 8
+	case 2:
+		r.setReg(z, v&^(1<<y))
+	case 3:
+		r.setReg(z, v|1<<y)
+	}
+
+	if z == 6 {
+		return 16
+	}
+	return 8
+}
@@ -1,21 +1,73 @@
 package cpu
 
-import "github.com/mrratatosk/oort-framework/register"
+import (
+	"github.com/mrratatosk/oort-framework/register"
+	"github.com/mrratatosk/oort-framework/tools"
+)
+
+// Registers is the register file of the CPU. Fields are accessed directly on
+// the hot path, the 16-bit pairs are views over their two 8-bit halves.
+type Registers struct {
+	A  uint8
+	F  uint8
+	B  uint8
+	C  uint8
+	D  uint8
+	E  uint8
+	H  uint8
+	L  uint8
+	SP uint16
+	PC uint16
+}
+
+func (r *Registers) AF() uint16 {
+	return uint16(r.A)<<8 | uint16(r.F)
+}
+
+// SetAF drops the low nibble of F, it always reads as zero.
+func (r *Registers) SetAF(value uint16) {
+	r.A, r.F = tools.Split8(value)
+	r.F &= 0xF0
+}
+
+func (r *Registers) BC() uint16 {
+	return uint16(r.B)<<8 | uint16(r.C)
+}
+
+func (r *Registers) SetBC(value uint16) {
+	r.B, r.C = tools.Split8(value)
+}
+
+func (r *Registers) DE() uint16 {
+	return uint16(r.D)<<8 | uint16(r.E)
+}
+
+func (r *Registers) SetDE(value uint16) {
+	r.D, r.E = tools.Split8(value)
+}
+
+func (r *Registers) HL() uint16 {
+	return uint16(r.H)<<8 | uint16(r.L)
+}
+
+func (r *Registers) SetHL(value uint16) {
+	r.H, r.L = tools.Split8(value)
+}
 
-func registers() register.RegisterList {
+// RegisterList copies the registers into the framework representation, for
+// tooling that works on any processor unit. Changes to it are not reflected.
+func (r Registers) RegisterList() register.RegisterList {
 	rl := register.NewRegisterList()
 
-	rl.AddRegister8("A")
-	rl.AddRegister8("B")
-	rl.AddRegister8("C")
-	rl.AddRegister8("D")
-	rl.AddRegister8("E")
-	rl.AddRegister8("F")
-	rl.AddRegister8("H")
-	rl.AddRegister8("L")
+	for name, value := range map[string]uint8{"A": r.A, "B": r.B, "C": r.C, "D": r.D, "E": r.E, "F": r.F, "H": r.H, "L": r.L} {
+		rl.AddRegister8(name)
+		rl.Set8(name, value)
+	}
 
 	rl.AddRegister16("PC")
+	rl.Set16("PC", r.PC)
 	rl.AddRegister16("SP")
+	rl.Set16("SP", r.SP)
 
 	return rl
 }
@@ -23,8 +75,8 @@ func registers() register.RegisterList {
 type Flags uint8
 
 const (
-	Z Flags = 4
-	N       = 5
-	H       = 6
-	C       = 7
+	Z Flags = 7
+	N Flags = 6
+	H Flags = 5
+	C Flags = 4
 )
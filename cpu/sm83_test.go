@@ -0,0 +1,282 @@
+package cpu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mrratatosk/oort-framework/memory"
+)
+
+// The single-step vectors are not vendored, clone them from
+// https://github.com/SingleStepTests/sm83 and copy the v1 directory to
+// testdata/sm83 (or point SM83_TESTS to it) to run this suite.
+const sm83Dir = "testdata/sm83"
+
+// An instruction never lasts more than 6 M-cycles, any longer is a hang.
+const sm83MaxTicks = 8
+
+type sm83State struct {
+	PC  uint16      `json:"pc"`
+	SP  uint16      `json:"sp"`
+	A   uint8       `json:"a"`
+	B   uint8       `json:"b"`
+	C   uint8       `json:"c"`
+	D   uint8       `json:"d"`
+	E   uint8       `json:"e"`
+	F   uint8       `json:"f"`
+	H   uint8       `json:"h"`
+	L   uint8       `json:"l"`
+	IME uint8       `json:"ime"`
+	IE  uint8       `json:"ie"`
+	RAM [][2]uint16 `json:"ram"`
+}
+
+type sm83Test struct {
+	Name    string      `json:"name"`
+	Initial sm83State   `json:"initial"`
+	Final   sm83State   `json:"final"`
+	Cycles  []sm83Cycle `json:"cycles"`
+}
+
+// sm83Cycle is an M-cycle of the vectors, [address, value, pins]: pins is
+// "r-m" for a memory read, "-wm" for a write and "---" for an idle cycle,
+// whose value is null.
+type sm83Cycle struct {
+	Address uint16
+	Value   *uint8
+	Pins    string
+}
+
+func (s *sm83Cycle) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*s = sm83Cycle{Pins: "---"}
+		return nil
+	}
+
+	var fields []json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if len(fields) != 3 {
+		return fmt.Errorf("cycle %s: want [address, value, pins]", data)
+	}
+
+	for i, field := range []interface{}{&s.Address, &s.Value, &s.Pins} {
+		if err := json.Unmarshal(fields[i], field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s sm83Cycle) accesses() bool {
+	return strings.ContainsAny(s.Pins, "rw")
+}
+
+// matches tells whether a is the access of the cycle, a null value is not
+// checked.
+func (s sm83Cycle) matches(a busAccess) bool {
+	return a.address == s.Address && a.write == strings.Contains(s.Pins, "w") && (s.Value == nil || a.value == *s.Value)
+}
+
+func (s sm83Cycle) String() string {
+	if s.Value == nil {
+		return fmt.Sprintf("%s at %04X", s.Pins, s.Address)
+	}
+
+	return fmt.Sprintf("%s %02X at %04X", s.Pins, *s.Value, s.Address)
+}
+
+type busAccess struct {
+	address uint16
+	value   uint8
+	write   bool
+}
+
+func (a busAccess) String() string {
+	op := "read"
+	if a.write {
+		op = "write"
+	}
+
+	return fmt.Sprintf("%s %02X at %04X", op, a.value, a.address)
+}
+
+// recordingBus logs the accesses of the CPU to memory.
+type recordingBus struct {
+	mem      *memory.Memory[uint16, uint8]
+	accesses []busAccess
+}
+
+func (b *recordingBus) Read(address uint16) uint8 {
+	value := b.mem.Read(address)
+	b.accesses = append(b.accesses, busAccess{address, value, false})
+
+	return value
+}
+
+func (b *recordingBus) Write(address uint16, value uint8) {
+	b.accesses = append(b.accesses, busAccess{address, value, true})
+	b.mem.Write(address, value)
+}
+
+func TestSM83(t *testing.T) {
+	dir := os.Getenv("SM83_TESTS")
+	if dir == "" {
+		dir = sm83Dir
+	}
+
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		t.Skipf("no single-step vectors in %s", dir)
+	}
+
+	for op, ins := range instructionSet {
+		runSM83File(t, fmt.Sprintf("%02X %s", op, ins.Name), filepath.Join(dir, fmt.Sprintf("%02x.json", op)))
+	}
+
+	for op, ins := range extensionSet {
+		runSM83File(t, fmt.Sprintf("CB %02X %s", op, ins.Name), filepath.Join(dir, fmt.Sprintf("cb %02x.json", op)))
+	}
+}
+
+func runSM83File(t *testing.T, name string, path string) {
+	t.Run(name, func(t *testing.T) {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			t.Skipf("no vectors in %s", path)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var tests []sm83Test
+		if err := json.Unmarshal(data, &tests); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+
+		failed := 0
+		for _, test := range tests {
+			if err := runSM83(test); err != nil {
+				if failed < 5 {
+					t.Errorf("%s: %v", test.Name, err)
+				}
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			t.Errorf("%d/%d vectors failed", failed, len(tests))
+		}
+	})
+}
+
+func runSM83(test sm83Test) error {
+	mem := memory.NewMemory[uint16, uint8](0x10000)
+	bus := &recordingBus{mem: mem}
+	c := New(bus)
+
+	in := test.Initial
+	if err := c.SetState(State{A: in.A, F: in.F, B: in.B, C: in.C, D: in.D, E: in.E, H: in.H, L: in.L, SP: in.SP, PC: in.PC, IME: in.IME != 0}); err != nil {
+		return err
+	}
+
+	mem.Write(InterruptEnable, in.IE)
+	for _, cell := range in.RAM {
+		mem.Write(cell[0], uint8(cell[1]))
+	}
+
+	var cycles [][]busAccess
+	for len(cycles) == 0 || c.step < len(c.ins.Steps) || c.prefix != nil {
+		if len(cycles) == sm83MaxTicks {
+			return fmt.Errorf("instruction did not complete in %d cycles", len(cycles))
+		}
+
+		bus.accesses = nil
+		tick(c, 1)
+		cycles = append(cycles, bus.accesses)
+	}
+
+	var errs []string
+	check := func(name string, got uint16, want uint16) {
+		if got != want {
+			errs = append(errs, fmt.Sprintf("%s = %04X, want %04X", name, got, want))
+		}
+	}
+
+	for i := 0; i < len(cycles) && i < len(test.Cycles); i++ {
+		want, got := test.Cycles[i], cycles[i]
+		switch {
+		case !want.accesses() && len(got) != 0:
+			errs = append(errs, fmt.Sprintf("cycle %d: %v, want no access", i, got))
+		case want.accesses() && (len(got) != 1 || !want.matches(got[0])):
+			errs = append(errs, fmt.Sprintf("cycle %d: %v, want %v", i, got, want))
+		}
+	}
+
+	out := test.Final
+	st := c.State()
+	for _, reg := range []struct {
+		name      string
+		got, want uint8
+	}{{"A", st.A, out.A}, {"B", st.B, out.B}, {"C", st.C, out.C}, {"D", st.D, out.D}, {"E", st.E, out.E}, {"F", st.F, out.F}, {"H", st.H, out.H}, {"L", st.L, out.L}} {
+		check(reg.name, uint16(reg.got), uint16(reg.want))
+	}
+	check("PC", st.PC, out.PC)
+	check("SP", st.SP, out.SP)
+
+	// the vectors stop right after the instruction, so the one instruction
+	// delay of EI cannot be observed and IME already reads as set
+	ime := uint16(0)
+	if st.IME || st.IMEPending {
+		ime = 1
+	}
+	check("IME", ime, uint16(out.IME))
+	check("IE", uint16(mem.Read(InterruptEnable)), uint16(out.IE))
+
+	for _, cell := range out.RAM {
+		check(fmt.Sprintf("(%04X)", cell[0]), uint16(mem.Read(cell[0])), cell[1])
+	}
+	check("cycles", uint16(len(cycles)), uint16(len(test.Cycles)))
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// The bus activity is checked cycle by cycle, even without the vectors.
+func TestSM83Cycles(t *testing.T) {
+	// PUSH BC, as laid out in the vectors
+	vector := `{
+		"name": "c5 0000",
+		"initial": {"pc": 256, "sp": 53248, "a": 0, "b": 18, "c": 52, "d": 0, "e": 0, "f": 0, "h": 0, "l": 0, "ime": 0, "ie": 0, "ram": [[256, 197]]},
+		"final": {"pc": 257, "sp": 53246, "a": 0, "b": 18, "c": 52, "d": 0, "e": 0, "f": 0, "h": 0, "l": 0, "ime": 0, "ie": 0, "ram": [[256, 197], [53247, 18], [53246, 52]]},
+		"cycles": [[256, 197, "r-m"], [53248, null, "---"], [53247, 18, "-wm"], [53246, 52, "-wm"]]
+	}`
+
+	var test sm83Test
+	if err := json.Unmarshal([]byte(vector), &test); err != nil {
+		t.Fatal(err)
+	}
+	if err := runSM83(test); err != nil {
+		t.Fatal(err)
+	}
+
+	test.Cycles[2], test.Cycles[3] = test.Cycles[3], test.Cycles[2]
+	if err := runSM83(test); err == nil || !strings.Contains(err.Error(), "cycle 2") {
+		t.Errorf("swapped writes: got %v", err)
+	}
+
+	test.Cycles[1].Pins = "r-m"
+	if err := runSM83(test); err == nil || !strings.Contains(err.Error(), "cycle 1") {
+		t.Errorf("read in an idle cycle: got %v", err)
+	}
+}
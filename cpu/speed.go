@@ -0,0 +1,78 @@
+package cpu
+
+// Model is the Game Boy the CPU runs in, some behaviours differ between them.
+type Model uint8
+
+const (
+	DMG Model = iota
+	CGB
+)
+
+// SpeedRegister is KEY1: bit 0 arms a speed switch, bit 7 reads the current
+// speed. The unused bits read 1. It is owned by the CPU, see Key1.
+const SpeedRegister uint16 = 0xFF4D
+
+// speedSwitchCycles are the M-cycles the CPU pauses for while the clock
+// switches speed.
+const speedSwitchCycles = 2050
+
+func (c *Cpu) SetModel(m Model) {
+	c.model = m
+}
+
+func (c Cpu) Model() Model {
+	return c.model
+}
+
+// DoubleSpeed reports whether a CGB runs the CPU clock, and the timer, serial
+// port and DMA with it, at twice the normal rate.
+func (c Cpu) DoubleSpeed() bool {
+	return c.doubleSpeed
+}
+
+// Key1 serves SpeedRegister, to be mapped in the IO registers. A DMG has no
+// KEY1, it reads 0xFF and ignores writes.
+type Key1 struct {
+	c *Cpu
+}
+
+func (c *Cpu) Key1() Key1 {
+	return Key1{c}
+}
+
+func (k Key1) Read(address uint16) uint8 {
+	if k.c.model != CGB {
+		return 0xFF
+	}
+
+	value := uint8(0x7E)
+	if k.c.doubleSpeed {
+		value |= 0x80
+	}
+	if k.c.speedArmed {
+		value |= 0x01
+	}
+
+	return value
+}
+
+// Write only sets bit 0, the current speed is read-only.
+func (k Key1) Write(address uint16, value uint8) {
+	if k.c.model == CGB {
+		k.c.speedArmed = value&0x01 != 0
+	}
+}
+
+// switchSpeed is what STOP does on a CGB once KEY1 armed a switch, in place of
+// stopping the clock.
+func (c *Cpu) switchSpeed() bool {
+	if c.model != CGB || !c.speedArmed {
+		return false
+	}
+
+	c.doubleSpeed = !c.doubleSpeed
+	c.speedArmed = false
+	c.switching = speedSwitchCycles
+
+	return true
+}
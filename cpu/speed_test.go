@@ -0,0 +1,65 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/mrratatosk/oort-gb/mmu"
+)
+
+func TestSpeedSwitch(t *testing.T) {
+	setup := func(model Model) (*Cpu, *mmu.MMU) {
+		c, mem := newTestCpu(0x100,
+			0x3E, 0x01, // LD A,1
+			0xE0, 0x4D, // LDH ($4D),A
+			0x10, 0x00, // STOP
+			0xAF,       // XOR A
+			0xE0, 0x4D, // LDH ($4D),A
+			0xF0, 0x4D, // LDH A,($4D)
+			0x18, 0xFE, // JR $
+		)
+
+		bus := onMMU(c, mem)
+		bus.MapIO(SpeedRegister, SpeedRegister, c.Key1())
+		c.SetModel(model)
+
+		return c, bus
+	}
+
+	c, bus := setup(CGB)
+	tick(c, 2+3)
+	if key1 := bus.Read(SpeedRegister); key1 != 0x7F {
+		t.Errorf("armed KEY1 = 0x%02X, want 0x7F", key1)
+	}
+
+	tick(c, 1+speedSwitchCycles)
+	if !c.DoubleSpeed() || c.ClockDivider() != 2 || c.Stopped() {
+		t.Fatalf("double speed %v, divider %d, stopped %v", c.DoubleSpeed(), c.ClockDivider(), c.Stopped())
+	}
+	if key1 := bus.Read(SpeedRegister); key1 != 0xFE {
+		t.Errorf("KEY1 = 0x%02X, want 0xFE", key1)
+	}
+	if c.Registers.PC != 0x106 {
+		t.Fatalf("PC = 0x%04X during the speed switch pause", c.Registers.PC)
+	}
+
+	// writing 0 must not clear the read-only speed bit
+	tick(c, 1+3+3)
+	if c.Registers.A != 0xFE || !c.DoubleSpeed() {
+		t.Errorf("KEY1 read 0x%02X after writing 0, double speed %v", c.Registers.A, c.DoubleSpeed())
+	}
+
+	c.Registers.PC = 0x104
+	tick(c, 1)
+	if !c.DoubleSpeed() || !c.Stopped() {
+		t.Errorf("STOP without KEY1 armed: double speed %v, stopped %v", c.DoubleSpeed(), c.Stopped())
+	}
+
+	dmg, bus := setup(DMG)
+	tick(dmg, 2+3+1)
+	if dmg.DoubleSpeed() || !dmg.Stopped() {
+		t.Errorf("DMG STOP: double speed %v, stopped %v", dmg.DoubleSpeed(), dmg.Stopped())
+	}
+	if key1 := bus.Read(SpeedRegister); key1 != 0xFF {
+		t.Errorf("DMG KEY1 = 0x%02X, want 0xFF", key1)
+	}
+}
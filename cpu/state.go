@@ -0,0 +1,107 @@
+package cpu
+
+import "fmt"
+
+// State is a snapshot of the CPU as seen from outside. The Z, N, H and C
+// fields decode F, SetState ignores them and only restores F.
+type State struct {
+	A  uint8
+	F  uint8
+	B  uint8
+	C  uint8
+	D  uint8
+	E  uint8
+	H  uint8
+	L  uint8
+	SP uint16
+	PC uint16
+
+	FlagZ bool
+	FlagN bool
+	FlagH bool
+	FlagC bool
+
+	IME bool
+	// IMEPending is set between EI and the instruction boundary enabling IME.
+	IMEPending bool
+	Halted     bool
+	Stopped    bool
+	// DoubleSpeed is the CGB speed mode, SpeedArmed bit 0 of KEY1 and
+	// SwitchCycles the M-cycles left to a speed switch in progress.
+	DoubleSpeed  bool
+	SpeedArmed   bool
+	SwitchCycles uint
+
+	// PendingCycles counts the clock cycles left to the instruction in flight.
+	PendingCycles uint
+}
+
+func (c *Cpu) State() State {
+	r := c.Registers
+
+	return State{
+		A:             r.A,
+		F:             r.F,
+		B:             r.B,
+		C:             r.C,
+		D:             r.D,
+		E:             r.E,
+		H:             r.H,
+		L:             r.L,
+		SP:            r.SP,
+		PC:            r.PC,
+		FlagZ:         getFlag(c, Z),
+		FlagN:         getFlag(c, N),
+		FlagH:         getFlag(c, H),
+		FlagC:         getFlag(c, C),
+		IME:           c.ime,
+		IMEPending:    c.imeScheduled,
+		Halted:        c.halted,
+		Stopped:       c.stopped,
+		DoubleSpeed:   c.doubleSpeed,
+		SpeedArmed:    c.speedArmed,
+		SwitchCycles:  uint(c.switching),
+		PendingCycles: uint(len(c.ins.Steps)-c.step) * 4,
+	}
+}
+
+// SetState overwrites the CPU state and clears a crash. The low nibble of F
+// always reads 0 and is dropped. The steps of an instruction in flight cannot
+// be restored, PendingCycles only delays the next fetch by that many idle
+// cycles, and must be whole M-cycles.
+func (c *Cpu) SetState(s State) error {
+	if s.PendingCycles%4 != 0 {
+		return fmt.Errorf("cpu: %d pending cycles are not whole M-cycles", s.PendingCycles)
+	}
+
+	c.Registers = Registers{
+		A:  s.A,
+		F:  s.F & 0xF0,
+		B:  s.B,
+		C:  s.C,
+		D:  s.D,
+		E:  s.E,
+		H:  s.H,
+		L:  s.L,
+		SP: s.SP,
+		PC: s.PC,
+	}
+
+	c.ime = s.IME
+	c.imeScheduled = s.IMEPending
+	c.halted = s.Halted
+	c.stopped = s.Stopped
+	c.doubleSpeed = s.DoubleSpeed
+	c.speedArmed = s.SpeedArmed
+	c.switching = int(s.SwitchCycles)
+	c.haltBug = false
+	c.prefix = nil
+	c.current = nil
+	c.frames = nil
+	c.fault = nil
+
+	c.ins = Instruction{Name: "IDLE", Steps: make([]microOp, s.PendingCycles/4)}
+	c.step = 0
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package cpu
+
+import "testing"
+
+func TestSetState(t *testing.T) {
+	// INC A
+	c, _ := newTestCpu(0x100, 0x3C)
+
+	want := State{A: 0x12, F: 0xFF, B: 0x34, SP: 0xCFF0, PC: 0x100, IME: true, PendingCycles: 8}
+	if err := c.SetState(want); err != nil {
+		t.Fatal(err)
+	}
+
+	s := c.State()
+	if s.F != 0xF0 || !s.FlagZ || !s.FlagC {
+		t.Errorf("F = 0x%02X, the low nibble must read 0", s.F)
+	}
+	if s.A != 0x12 || s.B != 0x34 || s.SP != 0xCFF0 || !s.IME || s.PendingCycles != 8 {
+		t.Errorf("got %+v, want %+v", s, want)
+	}
+
+	// the pending cycles delay INC A
+	tick(c, 2)
+	if c.Registers.A != 0x12 {
+		t.Errorf("A = 0x%02X during the pending cycles", c.Registers.A)
+	}
+	tick(c, 1)
+	if c.Registers.A != 0x13 {
+		t.Errorf("A = 0x%02X after the pending cycles", c.Registers.A)
+	}
+
+	if err := c.SetState(State{A: 0x99, PendingCycles: 6}); err == nil {
+		t.Error("6 pending cycles were accepted")
+	}
+	if c.Registers.A != 0x13 {
+		t.Error("a rejected state was applied")
+	}
+}
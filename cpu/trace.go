@@ -0,0 +1,23 @@
+package cpu
+
+import (
+	"fmt"
+	"io"
+)
+
+// SetTracer logs every instruction executed from now on to w, in the format
+// of Gameboy Doctor so the output can be diffed against reference logs. A nil
+// writer turns tracing off.
+func (c *Cpu) SetTracer(w io.Writer) {
+	c.tracer = w
+}
+
+// trace logs the state at an instruction boundary, right before the opcode
+// fetch.
+func (c *Cpu) trace() {
+	r, pc := c.Registers, c.Registers.PC
+
+	fmt.Fprintf(c.tracer, "A:%02X F:%02X B:%02X C:%02X D:%02X E:%02X H:%02X L:%02X SP:%04X PC:%04X PCMEM:%02X,%02X,%02X,%02X\n",
+		r.A, r.F, r.B, r.C, r.D, r.E, r.H, r.L, r.SP, pc,
+		c.mem.Read(pc), c.mem.Read(pc+1), c.mem.Read(pc+2), c.mem.Read(pc+3))
+}
@@ -0,0 +1,31 @@
+package cpu
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	// NOP / JP $0150 ... LD A,$42 / ADD A,B / SWAP A
+	c, mem := newTestCpu(0x100, 0x00, 0xC3, 0x50, 0x01)
+	mem.WriteRange(0x150, []uint8{0x3E, 0x42, 0x80, 0xCB, 0x37})
+
+	// the state Gameboy Doctor logs start from
+	c.Registers = Registers{A: 0x01, F: 0xB0, B: 0x00, C: 0x13, D: 0x00, E: 0xD8, H: 0x01, L: 0x4D, SP: 0xFFFE, PC: 0x0100}
+
+	var log strings.Builder
+	c.SetTracer(&log)
+	tick(c, 1+4+2+1+2+1)
+
+	want := "" +
+		"A:01 F:B0 B:00 C:13 D:00 E:D8 H:01 L:4D SP:FFFE PC:0100 PCMEM:00,C3,50,01\n" +
+		"A:01 F:B0 B:00 C:13 D:00 E:D8 H:01 L:4D SP:FFFE PC:0101 PCMEM:C3,50,01,00\n" +
+		"A:01 F:B0 B:00 C:13 D:00 E:D8 H:01 L:4D SP:FFFE PC:0150 PCMEM:3E,42,80,CB\n" +
+		"A:42 F:B0 B:00 C:13 D:00 E:D8 H:01 L:4D SP:FFFE PC:0152 PCMEM:80,CB,37,00\n" +
+		"A:42 F:00 B:00 C:13 D:00 E:D8 H:01 L:4D SP:FFFE PC:0153 PCMEM:CB,37,00,00\n" +
+		"A:24 F:00 B:00 C:13 D:00 E:D8 H:01 L:4D SP:FFFE PC:0155 PCMEM:00,00,00,00\n"
+
+	if log.String() != want {
+		t.Errorf("got\n%swant\n%s", log.String(), want)
+	}
+}
@@ -0,0 +1,120 @@
+// Package disasm turns SM83 machine code back into assembly, using the
+// mnemonics of the cpu opcode tables.
+package disasm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mrratatosk/oort-gb/cpu"
+)
+
+// Line is a single decoded instruction.
+type Line struct {
+	Address uint16
+	Bytes   []uint8
+	Text    string
+}
+
+func (l Line) String() string {
+	raw := make([]string, len(l.Bytes))
+	for i, b := range l.Bytes {
+		raw[i] = fmt.Sprintf("%02X", b)
+	}
+
+	return fmt.Sprintf("%04X  %-8s  %s", l.Address, strings.Join(raw, " "), l.Text)
+}
+
+// Decode disassembles the instruction at address, its bytes are read through
+// read.
+func Decode(read func(address uint16) uint8, address uint16) Line {
+	code := read(address)
+	ins, length := cpu.Opcode(code), uint16(1)
+	if ins.Name == "PREFIX CB" {
+		ins, length = cpu.ExtendedOpcode(read(address+1)), 2
+	}
+
+	if ins.Name == "ILLEGAL" {
+		return Line{Address: address, Bytes: []uint8{code}, Text: fmt.Sprintf("DB $%02X", code)}
+	}
+
+	bytes := make([]uint8, length+uint16(ins.Params))
+	for i := range bytes {
+		bytes[i] = read(address + uint16(i))
+	}
+
+	return Line{
+		Address: address,
+		Bytes:   bytes,
+		Text:    format(ins.Name, address+uint16(len(bytes)), bytes[length:]),
+	}
+}
+
+// Disassemble decodes a whole buffer, such as a ROM bank, mapped at origin.
+// An instruction cut by the end of the buffer is left as raw DB bytes.
+func Disassemble(data []uint8, origin uint16) []Line {
+	read := func(address uint16) uint8 {
+		if i := int(address - origin); i < len(data) {
+			return data[i]
+		}
+		return 0
+	}
+
+	var lines []Line
+	for pc := 0; pc < len(data); {
+		line := Decode(read, origin+uint16(pc))
+		if pc+len(line.Bytes) > len(data) {
+			for ; pc < len(data); pc++ {
+				lines = append(lines, Line{Address: origin + uint16(pc), Bytes: data[pc : pc+1], Text: fmt.Sprintf("DB $%02X", data[pc])})
+			}
+			break
+		}
+
+		lines = append(lines, line)
+		pc += len(line.Bytes)
+	}
+
+	return lines
+}
+
+// DisassembleRange decodes the instructions starting between from and to,
+// both included. The last one may extend past to.
+func DisassembleRange(mem cpu.Bus, from uint16, to uint16) []Line {
+	var lines []Line
+	for pc := uint32(from); pc <= uint32(to); {
+		line := Decode(mem.Read, uint16(pc))
+		lines = append(lines, line)
+		pc += uint32(len(line.Bytes))
+	}
+
+	return lines
+}
+
+// format substitutes the immediate operands of a mnemonic, next is the
+// address of the following instruction, relative jumps are relative to it.
+func format(mnemonic string, next uint16, imm []uint8) string {
+	name, operands, found := strings.Cut(mnemonic, " ")
+	if !found {
+		return name
+	}
+
+	args := strings.Split(operands, ",")
+	for i, arg := range args {
+		switch {
+		case strings.Contains(arg, "n16"), strings.Contains(arg, "a16"):
+			value := fmt.Sprintf("$%04X", uint16(imm[1])<<8|uint16(imm[0]))
+			args[i] = strings.NewReplacer("n16", value, "a16", value).Replace(arg)
+		case strings.Contains(arg, "n8"), strings.Contains(arg, "a8"):
+			value := fmt.Sprintf("$%02X", imm[0])
+			args[i] = strings.NewReplacer("n8", value, "a8", value).Replace(arg)
+		case name == "JR" && arg == "e8":
+			args[i] = fmt.Sprintf("$%04X", next+uint16(int8(imm[0])))
+		case arg == "SP+e8":
+			args[i] = fmt.Sprintf("SP%+d", int8(imm[0]))
+		case arg == "e8":
+			args[i] = fmt.Sprintf("%d", int8(imm[0]))
+		}
+	}
+
+	return name + " " + strings.Join(args, ",")
+}
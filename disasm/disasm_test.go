@@ -0,0 +1,59 @@
+package disasm
+
+import (
+	"testing"
+
+	"github.com/mrratatosk/oort-framework/memory"
+)
+
+func TestDisassemble(t *testing.T) {
+	code := []uint8{
+		0x00,
+		0x21, 0x00, 0xC0,
+		0x20, 0x4A,
+		0x18, 0xFE,
+		0xE0, 0x80,
+		0xF8, 0xFE,
+		0xE8, 0x05,
+		0xCB, 0x7C,
+		0xCB, 0x46,
+		0xD3,
+		0xC3, 0x50,
+	}
+
+	want := []string{
+		"0100  00        NOP",
+		"0101  21 00 C0  LD HL,$C000",
+		"0104  20 4A     JR NZ,$0150",
+		"0106  18 FE     JR $0106",
+		"0108  E0 80     LDH ($80),A",
+		"010A  F8 FE     LD HL,SP-2",
+		"010C  E8 05     ADD SP,5",
+		"010E  CB 7C     BIT 7,H",
+		"0110  CB 46     BIT 0,(HL)",
+		"0112  D3        DB $D3",
+		"0113  C3        DB $C3",
+		"0114  50        DB $50",
+	}
+
+	lines := Disassemble(code, 0x100)
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %v", len(lines), len(want), lines)
+	}
+
+	for i, line := range lines {
+		if line.String() != want[i] {
+			t.Errorf("line %d = %q, want %q", i, line.String(), want[i])
+		}
+	}
+}
+
+func TestDisassembleRange(t *testing.T) {
+	mem := memory.NewMemory[uint16, uint8](0x10000)
+	mem.WriteRange(0xFFFD, []uint8{0x3E, 0x42, 0xCD})
+
+	lines := DisassembleRange(mem, 0xFFFD, 0xFFFF)
+	if len(lines) != 2 || lines[0].Text != "LD A,$42" || lines[1].Text != "CALL $0000" {
+		t.Errorf("got %v", lines)
+	}
+}
@@ -1,6 +1,8 @@
 package oortgb
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"sync"
 
@@ -9,14 +11,54 @@ import (
 	"github.com/mrratatosk/oort-framework/processor"
 	"github.com/mrratatosk/oort-framework/tools"
 	"github.com/mrratatosk/oort-gb/cpu"
+	"github.com/mrratatosk/oort-gb/joypad"
+	"github.com/mrratatosk/oort-gb/mmu"
 )
 
 type GbEmulator struct {
 	oortframework.Emulator[uint16, uint8]
+	cpu *cpu.Cpu
+	mmu *mmu.MMU
+	pad *joypad.Joypad
+	run *runState
 }
 
+// runState is kept across calls to Run.
+type runState struct {
+	clock uint
+	mutex sync.Mutex
+	err   error
+}
+
+// fail records the first panic of a unit other than the CPU, which reports
+// its own crashes.
+func (r *runState) fail(err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// cpuClocked is implemented by the units clocked with the CPU, such as the
+// timer, serial port and DMA: they run at double rate in CGB double speed mode
+// while the PPU and APU keep the normal one.
+type cpuClocked interface {
+	CPUClocked()
+}
+
+// frameCycles is the length of a frame in clock cycles.
+const frameCycles = 70224
+
 func New(biosPath string) GbEmulator {
 	mem := memory.NewMemory[uint16, uint8](0x10000)
+	bus := mmu.New(mem)
+	c := cpu.New(bus)
+	bus.MapIO(cpu.SpeedRegister, cpu.SpeedRegister, c.Key1())
+
+	pad := joypad.New(func() { c.RequestInterrupt(cpu.Joypad) })
+	bus.MapIO(cpu.JoypadRegister, cpu.JoypadRegister, pad)
 
 	gb := GbEmulator{
 		oortframework.Emulator[uint16, uint8]{
@@ -25,14 +67,82 @@ func New(biosPath string) GbEmulator {
 			Units: []processor.ITicker{
 				//ppu.New(),
 				//apu.New(),
-				cpu.New(mem),
+				c,
 			},
 		},
+		c,
+		bus,
+		pad,
+		&runState{},
 	}
 
 	return gb
 }
 
+// Err reports the fault that stopped the emulator, if any: a *cpu.CrashError
+// or the panic of another unit.
+func (gb GbEmulator) Err() error {
+	if err := gb.cpu.Err(); err != nil {
+		return err
+	}
+
+	gb.run.mutex.Lock()
+	defer gb.run.mutex.Unlock()
+
+	return gb.run.err
+}
+
+// MMU returns the memory map, to hand its regions over to the cartridge and
+// the other units. Memory stays the backing store of the default handlers.
+func (gb GbEmulator) MMU() *mmu.MMU {
+	return gb.mmu
+}
+
+// Joypad returns the buttons, for the host to press and release them.
+func (gb GbEmulator) Joypad() *joypad.Joypad {
+	return gb.pad
+}
+
+// SetModel selects the hardware the CPU behaves as, see cpu.SetModel.
+func (gb GbEmulator) SetModel(m cpu.Model) {
+	gb.cpu.SetModel(m)
+}
+
+// SetOAMScan tells the CPU which OAM row the PPU scans, see cpu.SetOAMScan.
+func (gb GbEmulator) SetOAMScan(scan cpu.OAMScan) {
+	gb.cpu.SetOAMScan(scan)
+}
+
+// SetTracer logs every instruction the CPU executes to w, see cpu.SetTracer.
+func (gb GbEmulator) SetTracer(w io.Writer) {
+	gb.cpu.SetTracer(w)
+}
+
+// AddBreakpoint installs a breakpoint or watchpoint, see cpu.AddBreakpoint.
+func (gb GbEmulator) AddBreakpoint(b cpu.Breakpoint) int {
+	return gb.cpu.AddBreakpoint(b)
+}
+
+func (gb GbEmulator) RemoveBreakpoint(id int) {
+	gb.cpu.RemoveBreakpoint(id)
+}
+
+// Backtrace returns the shadow call stack of the CPU, innermost frame first.
+func (gb GbEmulator) Backtrace() []cpu.Frame {
+	return gb.cpu.Backtrace()
+}
+
+// SetHistorySize sets how many executed instructions the CPU keeps.
+func (gb GbEmulator) SetHistorySize(n int) {
+	gb.cpu.SetHistorySize(n)
+}
+
+// History returns the last instructions the CPU executed, oldest first, to
+// dump how a crash or breakpoint was reached.
+func (gb GbEmulator) History() []cpu.HistoryEntry {
+	return gb.cpu.History()
+}
+
 func (gb GbEmulator) loadBios() {
 	dat, err := os.ReadFile(gb.Bios)
 	tools.Check(err)
@@ -40,26 +150,73 @@ func (gb GbEmulator) loadBios() {
 	gb.Memory.WriteRange(0x0, dat)
 }
 
+// Start runs the BIOS until a breakpoint pauses the CPU or a fault stops it,
+// see Err.
 func (gb GbEmulator) Start() {
 	gb.loadBios()
 
+	for {
+		if hit, err := gb.Run(frameCycles); hit != nil || err != nil {
+			return
+		}
+	}
+}
+
+// Run executes up to cycles clock cycles, returning early with the breakpoint
+// that paused the CPU or the fault that stopped the emulator. A paused CPU is
+// resumed first.
+func (gb GbEmulator) Run(cycles uint) (*cpu.Hit, error) {
+	gb.cpu.Resume()
+
 	var wg sync.WaitGroup
-	cycles, stop := uint8(0), uint8(0)
+	for i := uint(0); i < cycles; i++ {
+		if err := gb.Err(); err != nil {
+			return nil, err
+		}
+		if hit := gb.cpu.Break(); hit != nil {
+			return hit, nil
+		}
+
+		gb.tick(&wg)
+	}
+
+	return gb.cpu.Break(), gb.Err()
+}
 
-	for stop < 10 {
-		if cycles >= 4 {
-			cycles = 0
-			stop++
+// tick runs one clock cycle, each unit is ticked on its own divider. The CPU
+// divider already follows the speed mode, the other CPU clocked units have
+// theirs halved in double speed.
+func (gb GbEmulator) tick(wg *sync.WaitGroup) {
+	stopped, double := gb.cpu.Stopped(), gb.cpu.DoubleSpeed()
+	for _, unit := range gb.Units {
+		if stopped && unit != gb.cpu {
+			continue
 		}
 
-		for _, unit := range gb.Units {
-			if cycles%unit.ClockDivider() == 0 {
-				wg.Add(1)
-				go unit.Tick(&wg)
-			}
+		divider := uint(unit.ClockDivider())
+		if _, ok := unit.(cpuClocked); ok && double && divider > 1 {
+			divider /= 2
 		}
 
-		wg.Wait()
-		cycles++
+		if gb.run.clock%divider == 0 {
+			wg.Add(1)
+			go gb.tickUnit(unit, wg)
+		}
 	}
+
+	wg.Wait()
+	gb.run.clock++
+}
+
+// tickUnit recovers from a panic of the unit, which then never got to call
+// wg.Done.
+func (gb GbEmulator) tickUnit(unit processor.ITicker, wg *sync.WaitGroup) {
+	defer func() {
+		if r := recover(); r != nil {
+			gb.run.fail(fmt.Errorf("%T panicked: %v", unit, r))
+			wg.Done()
+		}
+	}()
+
+	unit.Tick(wg)
 }
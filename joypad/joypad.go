@@ -0,0 +1,70 @@
+// Package joypad implements P1, the register the buttons are read through.
+package joypad
+
+type Button uint8
+
+const (
+	Right Button = iota
+	Left
+	Up
+	Down
+	A
+	B
+	Select
+	Start
+)
+
+// The select bits of P1: a 0 connects the directions, or the buttons, to the
+// input lines.
+const (
+	selectDirections uint8 = 0x10
+	selectButtons    uint8 = 0x20
+)
+
+// Joypad serves P1. The input lines in the low nibble read 0 for a pressed
+// button of a selected group, and 1 otherwise: P1 reads 0xCF at power-on.
+type Joypad struct {
+	pressed   uint8
+	selection uint8
+	interrupt func()
+}
+
+// New returns a joypad with no button pressed, interrupt is called when a
+// button press pulls a line low.
+func New(interrupt func()) *Joypad {
+	return &Joypad{interrupt: interrupt}
+}
+
+func (j *Joypad) lines() uint8 {
+	lines := uint8(0x0F)
+	if j.selection&selectDirections == 0 {
+		lines &^= j.pressed & 0x0F
+	}
+	if j.selection&selectButtons == 0 {
+		lines &^= j.pressed >> 4
+	}
+
+	return lines
+}
+
+func (j *Joypad) Press(b Button) {
+	before := j.lines()
+	j.pressed |= 1 << b
+
+	if j.lines() != before && j.interrupt != nil {
+		j.interrupt()
+	}
+}
+
+func (j *Joypad) Release(b Button) {
+	j.pressed &^= 1 << b
+}
+
+func (j *Joypad) Read(address uint16) uint8 {
+	return 0xC0 | j.selection | j.lines()
+}
+
+// Write only sets the select bits, the lines are read-only.
+func (j *Joypad) Write(address uint16, value uint8) {
+	j.selection = value & (selectDirections | selectButtons)
+}
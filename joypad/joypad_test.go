@@ -0,0 +1,34 @@
+package joypad
+
+import "testing"
+
+func TestJoypad(t *testing.T) {
+	interrupts := 0
+	j := New(func() { interrupts++ })
+
+	if p1 := j.Read(0xFF00); p1 != 0xCF {
+		t.Errorf("P1 = 0x%02X at power-on, want 0xCF", p1)
+	}
+
+	j.Write(0xFF00, 0x20)
+	j.Press(Start)
+	if p1 := j.Read(0xFF00); p1 != 0xEF || interrupts != 0 {
+		t.Errorf("Start with the directions selected: P1 = 0x%02X, %d interrupts", p1, interrupts)
+	}
+
+	j.Press(Up)
+	if p1 := j.Read(0xFF00); p1 != 0xEB || interrupts != 1 {
+		t.Errorf("Up: P1 = 0x%02X, %d interrupts", p1, interrupts)
+	}
+
+	j.Write(0xFF00, 0x10)
+	if p1 := j.Read(0xFF00); p1 != 0xD7 {
+		t.Errorf("Start with the buttons selected: P1 = 0x%02X", p1)
+	}
+
+	j.Release(Start)
+	j.Write(0xFF00, 0x00)
+	if p1 := j.Read(0xFF00); p1 != 0xCB {
+		t.Errorf("Up with both groups selected: P1 = 0x%02X", p1)
+	}
+}
@@ -0,0 +1,181 @@
+// Package mmu implements the Game Boy memory map, each region being served by
+// a handler that the cartridge, PPU, APU or timers can replace with their own.
+package mmu
+
+import (
+	"fmt"
+
+	"github.com/mrratatosk/oort-framework/memory"
+)
+
+// Handler serves the accesses to a region, addresses are absolute.
+type Handler interface {
+	Read(address uint16) uint8
+	Write(address uint16, value uint8)
+}
+
+type Region uint8
+
+const (
+	ROM0 Region = iota
+	ROMX
+	VRAM
+	ExternalRAM
+	WRAM
+	Echo
+	OAM
+	Unusable
+	IO
+	HRAM
+	IE
+	regionCount
+)
+
+// bounds are the first and last address of each region.
+var bounds = [regionCount][2]uint16{
+	ROM0:        {0x0000, 0x3FFF},
+	ROMX:        {0x4000, 0x7FFF},
+	VRAM:        {0x8000, 0x9FFF},
+	ExternalRAM: {0xA000, 0xBFFF},
+	WRAM:        {0xC000, 0xDFFF},
+	Echo:        {0xE000, 0xFDFF},
+	OAM:         {0xFE00, 0xFE9F},
+	Unusable:    {0xFEA0, 0xFEFF},
+	IO:          {0xFF00, 0xFF7F},
+	HRAM:        {0xFF80, 0xFFFE},
+	IE:          {0xFFFF, 0xFFFF},
+}
+
+func (r Region) String() string {
+	return [...]string{"ROM0", "ROMX", "VRAM", "external RAM", "WRAM", "echo RAM", "OAM", "unusable", "IO", "HRAM", "IE"}[r]
+}
+
+// Bounds returns the first and last address of the region.
+func (r Region) Bounds() (uint16, uint16) {
+	return bounds[r][0], bounds[r][1]
+}
+
+// RegionOf returns the region address belongs to.
+func RegionOf(address uint16) Region {
+	r := ROM0
+	for address > bounds[r][1] {
+		r++
+	}
+
+	return r
+}
+
+// MMU dispatches the accesses of the CPU to the handler of each region. IO
+// registers can also be handed out one range at a time, see MapIO.
+type MMU struct {
+	handlers [regionCount]Handler
+	io       [0x80]Handler
+}
+
+// New maps every region onto mem: ROM is read-only, echo RAM mirrors
+// 0xC000-0xDDFF, the unusable region reads 0x00 and ignores writes, and the
+// other regions, IO included, are plain bytes. mem is the backing store the
+// BIOS and ROM are loaded into.
+func New(mem *memory.Memory[uint16, uint8]) *MMU {
+	m := &MMU{}
+	for r := ROM0; r < regionCount; r++ {
+		m.handlers[r] = RAM{mem}
+	}
+
+	m.handlers[ROM0] = ROM{mem}
+	m.handlers[ROMX] = ROM{mem}
+	m.handlers[Echo] = echo{m}
+	m.handlers[Unusable] = unusable{}
+
+	return m
+}
+
+// Map hands region r over to h.
+func (m *MMU) Map(r Region, h Handler) {
+	m.handlers[r] = h
+}
+
+// Handler returns the handler of region r, to chain to it.
+func (m *MMU) Handler(r Region) Handler {
+	return m.handlers[r]
+}
+
+// MapIO hands the IO registers from start to end, both included, over to h.
+// The others stay with the IO region handler.
+func (m *MMU) MapIO(start uint16, end uint16, h Handler) {
+	first, last := bounds[IO][0], bounds[IO][1]
+	if start < first || end > last || start > end {
+		panic(fmt.Sprintf("mmu: 0x%04X-0x%04X is not an IO range", start, end))
+	}
+
+	for address := start; address <= end; address++ {
+		m.io[address-first] = h
+	}
+}
+
+func (m *MMU) handler(address uint16) Handler {
+	r := RegionOf(address)
+	if r == IO {
+		if h := m.io[address-bounds[IO][0]]; h != nil {
+			return h
+		}
+	}
+
+	return m.handlers[r]
+}
+
+func (m *MMU) Read(address uint16) uint8 {
+	return m.handler(address).Read(address)
+}
+
+func (m *MMU) Write(address uint16, value uint8) {
+	m.handler(address).Write(address, value)
+}
+
+// RAM reads and writes its addresses in a backing memory.
+type RAM struct {
+	Memory *memory.Memory[uint16, uint8]
+}
+
+func (r RAM) Read(address uint16) uint8 {
+	return r.Memory.Read(address)
+}
+
+func (r RAM) Write(address uint16, value uint8) {
+	r.Memory.Write(address, value)
+}
+
+// ROM reads its addresses in a backing memory and ignores writes, as a
+// cartridge without a memory bank controller.
+type ROM struct {
+	Memory *memory.Memory[uint16, uint8]
+}
+
+func (r ROM) Read(address uint16) uint8 {
+	return r.Memory.Read(address)
+}
+
+func (r ROM) Write(address uint16, value uint8) {}
+
+// echo mirrors WRAM through whatever handler serves it.
+type echo struct {
+	m *MMU
+}
+
+const echoOffset = 0x2000
+
+func (e echo) Read(address uint16) uint8 {
+	return e.m.Read(address - echoOffset)
+}
+
+func (e echo) Write(address uint16, value uint8) {
+	e.m.Write(address-echoOffset, value)
+}
+
+type unusable struct{}
+
+func (unusable) Read(address uint16) uint8 {
+	return 0x00
+}
+
+func (unusable) Write(address uint16, value uint8) {}
@@ -0,0 +1,90 @@
+package mmu
+
+import (
+	"testing"
+
+	"github.com/mrratatosk/oort-framework/memory"
+)
+
+func TestRegions(t *testing.T) {
+	for r := ROM0; r < regionCount; r++ {
+		start, end := r.Bounds()
+		if RegionOf(start) != r || RegionOf(end) != r {
+			t.Errorf("%s 0x%04X-0x%04X maps to %s-%s", r, start, end, RegionOf(start), RegionOf(end))
+		}
+		if r > ROM0 && start != bounds[r-1][1]+1 {
+			t.Errorf("%s starts at 0x%04X, after %s", r, start, r-1)
+		}
+	}
+}
+
+// register is a single IO register reading back its writes with the low bits
+// set, as the unused bits of most registers.
+type register struct {
+	value uint8
+}
+
+func (r *register) Read(address uint16) uint8 {
+	return r.value | 0x0F
+}
+
+func (r *register) Write(address uint16, value uint8) {
+	r.value = value
+}
+
+func TestMMU(t *testing.T) {
+	mem := memory.NewMemory[uint16, uint8](0x10000)
+	mem.WriteRange(0x0100, []uint8{0x00, 0xC3})
+	m := New(mem)
+
+	m.Write(0x0100, 0xFF)
+	m.Write(0x4000, 0xFF)
+	if m.Read(0x0100) != 0x00 || m.Read(0x4000) != 0x00 {
+		t.Error("ROM is writable")
+	}
+
+	m.Write(0xC123, 0x42)
+	if m.Read(0xE123) != 0x42 {
+		t.Errorf("echo RAM reads 0x%02X, want 0x42", m.Read(0xE123))
+	}
+	m.Write(0xFDFF, 0x24)
+	if mem.Read(0xDDFF) != 0x24 || mem.Read(0xFDFF) != 0x00 {
+		t.Error("echo RAM write does not land in WRAM")
+	}
+
+	m.Write(0xFEA0, 0x42)
+	if m.Read(0xFEA0) != 0x00 {
+		t.Errorf("unusable region reads 0x%02X", m.Read(0xFEA0))
+	}
+
+	m.Write(0xFF80, 0x11)
+	m.Write(0xFFFF, 0x1F)
+	if m.Read(0xFF80) != 0x11 || m.Read(0xFFFF) != 0x1F {
+		t.Error("HRAM or IE does not hold its value")
+	}
+
+	timer := &register{}
+	m.MapIO(0xFF05, 0xFF05, timer)
+	m.Write(0xFF05, 0x80)
+	m.Write(0xFF06, 0x80)
+	if timer.value != 0x80 || m.Read(0xFF05) != 0x8F || m.Read(0xFF06) != 0x80 {
+		t.Errorf("IO register 0xFF05 = 0x%02X, 0xFF06 = 0x%02X", m.Read(0xFF05), m.Read(0xFF06))
+	}
+
+	wram := &register{}
+	m.Map(WRAM, wram)
+	m.Write(0xE000, 0x30)
+	if wram.value != 0x30 || m.Read(0xC000) != 0x3F {
+		t.Error("echo RAM does not follow the WRAM handler")
+	}
+}
+
+func TestMapIOOutOfRange(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("mapping HRAM as IO did not panic")
+		}
+	}()
+
+	New(memory.NewMemory[uint16, uint8](0x10000)).MapIO(0xFF70, 0xFF80, &register{})
+}
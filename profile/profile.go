@@ -0,0 +1,152 @@
+// Package profile counts the instructions executed by the CPU and the cycles
+// they consumed, per opcode and per address, to find where a game spends its
+// frame budget.
+package profile
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/mrratatosk/oort-gb/cpu"
+)
+
+// Location is an instruction address qualified by the ROM bank mapped there.
+type Location struct {
+	Bank uint16
+	PC   uint16
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%02X:%04X", l.Bank, l.PC)
+}
+
+type Counter struct {
+	Count  uint64
+	Cycles uint64
+}
+
+// Hotspot is a counter labelled with its opcode name or location.
+type Hotspot struct {
+	Label string
+	Counter
+}
+
+type Profiler struct {
+	Opcodes   map[string]*Counter
+	Locations map[Location]*Counter
+	Total     Counter
+	bank      func(pc uint16) uint16
+}
+
+// New returns a profiler resolving the bank of an address with bank, nil
+// assumes a cartridge without mapper, see FixedBanks.
+func New(bank func(pc uint16) uint16) *Profiler {
+	if bank == nil {
+		bank = FixedBanks
+	}
+
+	p := &Profiler{bank: bank}
+	p.Reset()
+
+	return p
+}
+
+// FixedBanks maps ROM0 to bank 0 and ROMX to bank 1, it is cpu.FixedBanks.
+func FixedBanks(pc uint16) uint16 {
+	return cpu.FixedBanks(pc)
+}
+
+// Attach starts profiling every instruction c executes.
+func (p *Profiler) Attach(c *cpu.Cpu) {
+	c.AfterInstruction(p.record)
+}
+
+func (p *Profiler) Reset() {
+	p.Opcodes = map[string]*Counter{}
+	p.Locations = map[Location]*Counter{}
+	p.Total = Counter{}
+}
+
+func (p *Profiler) record(e cpu.Execution) {
+	cycles := uint64(e.Cycles)
+	p.Total.add(cycles)
+
+	name := e.Instruction.Name
+	if p.Opcodes[name] == nil {
+		p.Opcodes[name] = &Counter{}
+	}
+	p.Opcodes[name].add(cycles)
+
+	at := Location{p.bank(e.PC), e.PC}
+	if p.Locations[at] == nil {
+		p.Locations[at] = &Counter{}
+	}
+	p.Locations[at].add(cycles)
+}
+
+func (c *Counter) add(cycles uint64) {
+	c.Count++
+	c.Cycles += cycles
+}
+
+// HotOpcodes returns the n opcodes that consumed the most cycles.
+func (p *Profiler) HotOpcodes(n int) []Hotspot {
+	spots := make([]Hotspot, 0, len(p.Opcodes))
+	for name, c := range p.Opcodes {
+		spots = append(spots, Hotspot{name, *c})
+	}
+
+	return hottest(spots, n)
+}
+
+// HotLocations returns the n addresses that consumed the most cycles.
+func (p *Profiler) HotLocations(n int) []Hotspot {
+	spots := make([]Hotspot, 0, len(p.Locations))
+	for at, c := range p.Locations {
+		spots = append(spots, Hotspot{at.String(), *c})
+	}
+
+	return hottest(spots, n)
+}
+
+func hottest(spots []Hotspot, n int) []Hotspot {
+	sort.Slice(spots, func(i, j int) bool {
+		if spots[i].Cycles != spots[j].Cycles {
+			return spots[i].Cycles > spots[j].Cycles
+		}
+		return spots[i].Label < spots[j].Label
+	})
+
+	if n < len(spots) {
+		spots = spots[:n]
+	}
+
+	return spots
+}
+
+// Report writes the n hottest opcodes and locations to w.
+func (p *Profiler) Report(w io.Writer, n int) {
+	fmt.Fprintf(w, "%d instructions, %d cycles\n", p.Total.Count, p.Total.Cycles)
+
+	for _, section := range []struct {
+		title string
+		spots []Hotspot
+	}{
+		{"opcode", p.HotOpcodes(n)},
+		{"location", p.HotLocations(n)},
+	} {
+		fmt.Fprintf(w, "\n%-12s %10s %12s %7s\n", section.title, "count", "cycles", "share")
+		for _, s := range section.spots {
+			fmt.Fprintf(w, "%-12s %10d %12d %6.2f%%\n", s.Label, s.Count, s.Cycles, p.share(s.Cycles))
+		}
+	}
+}
+
+func (p *Profiler) share(cycles uint64) float64 {
+	if p.Total.Cycles == 0 {
+		return 0
+	}
+
+	return float64(cycles) * 100 / float64(p.Total.Cycles)
+}
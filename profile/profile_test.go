@@ -0,0 +1,47 @@
+package profile
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mrratatosk/oort-framework/memory"
+	"github.com/mrratatosk/oort-gb/cpu"
+)
+
+func TestProfiler(t *testing.T) {
+	mem := memory.NewMemory[uint16, uint8](0x10000)
+	// LD B,3 / loop: DEC B / JR NZ,loop / NOP
+	mem.WriteRange(0x4000, []uint8{0x06, 0x03, 0x05, 0x20, 0xFD, 0x00})
+
+	c := cpu.New(mem)
+	c.Registers.PC = 0x4000
+
+	p := New(nil)
+	p.Attach(c)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2+3*1+2*3+2+1; i++ {
+		wg.Add(1)
+		c.Tick(&wg)
+	}
+
+	if p.Total != (Counter{Count: 8, Cycles: 56}) {
+		t.Errorf("total = %+v", p.Total)
+	}
+
+	opcodes := p.HotOpcodes(2)
+	if len(opcodes) != 2 || opcodes[0] != (Hotspot{"JR NZ,e8", Counter{3, 32}}) || opcodes[1] != (Hotspot{"DEC B", Counter{3, 12}}) {
+		t.Errorf("hot opcodes = %v", opcodes)
+	}
+
+	if at := p.Locations[Location{1, 0x4003}]; at == nil || *at != (Counter{3, 32}) {
+		t.Errorf("01:4003 = %v", at)
+	}
+
+	var report strings.Builder
+	p.Report(&report, 1)
+	if !strings.Contains(report.String(), "01:4003               3           32  57.14%") {
+		t.Errorf("report:\n%s", report.String())
+	}
+}